@@ -0,0 +1,207 @@
+package railroad
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var bikeshedLineRegexp = regexp.MustCompile(`^(\w+)(?:[ \t]+([^:]*?))?[ \t]*:[ \t]*(.*)$`)
+
+// bikeshedCommands maps the names and abbreviations of commands to the
+// constructor they use.
+var bikeshedCommands = map[string]string{
+	"T":                "Terminal",
+	"Terminal":         "Terminal",
+	"N":                "NonTerminal",
+	"NonTerminal":      "NonTerminal",
+	"C":                "Comment",
+	"Comment":          "Comment",
+	"S":                "Skip",
+	"Skip":             "Skip",
+	"And":              "Sequence",
+	"Seq":              "Sequence",
+	"Sequence":         "Sequence",
+	"Stack":            "Stack",
+	"Or":               "Choice",
+	"Choice":           "Choice",
+	"Opt":              "Optional",
+	"Optional":         "Optional",
+	"Maybe":            "Optional",
+	"Plus":             "OneOrMore",
+	"OneOrMore":        "OneOrMore",
+	"Star":             "ZeroOrMore",
+	"ZeroOrMore":       "ZeroOrMore",
+	"OptSeq":           "OptionalSequence",
+	"OptionalSequence": "OptionalSequence",
+}
+
+type bikeshedNode struct {
+	line     int
+	indent   int
+	command  string
+	prelude  string
+	text     string
+	children []*bikeshedNode
+}
+
+// ReadBikeshed reads diagrams written in the indented text format Bikeshed
+// uses for railroad diagrams, returning the top level items to be passed to
+// Diagram. Each line is a command, optionally followed by a prelude, a colon
+// and text, and the lines indented under it are its children:
+//
+//	Seq:
+//		T: /*
+//		Star:
+//			N: anything but * followed by /
+//		T: */
+//
+// The prelude of Or is the index of the default branch, and Opt and Star
+// accept "skip" to make skipping the default. A second child of Plus or Star
+// is drawn as the repeat.
+func ReadBikeshed(r io.Reader) ([]RailItem, error) {
+	root := &bikeshedNode{indent: -1}
+	stack := []*bikeshedNode{root}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" {
+			continue
+		}
+		m := bikeshedLineRegexp.FindStringSubmatch(trimmed)
+		if m == nil {
+			return nil, fmt.Errorf("bikeshed: line %d: expected a command, found %q", line, trimmed)
+		}
+		command, ok := bikeshedCommands[m[1]]
+		if !ok {
+			return nil, fmt.Errorf("bikeshed: line %d: unknown command %q", line, m[1])
+		}
+		node := &bikeshedNode{
+			line:    line,
+			indent:  len(text) - len(trimmed),
+			command: command,
+			prelude: m[2],
+			text:    m[3],
+		}
+		for node.indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	items := make([]RailItem, 0, len(root.children))
+	for _, child := range root.children {
+		item, err := child.item()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (n *bikeshedNode) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bikeshed: line %d: %s: %s", n.line, n.command, fmt.Sprintf(format, args...))
+}
+
+func (n *bikeshedNode) item() (RailItem, error) {
+	children := make([]RailItem, 0, len(n.children))
+	for _, child := range n.children {
+		item, err := child.item()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, item)
+	}
+
+	switch n.command {
+	case "Terminal", "NonTerminal", "Comment", "Skip":
+		if len(children) > 0 {
+			return nil, n.errorf("unexpected children")
+		}
+	default:
+		if n.text != "" {
+			return nil, n.errorf("unexpected text %q", n.text)
+		}
+		if len(children) == 0 {
+			return nil, n.errorf("missing children")
+		}
+	}
+
+	skip := false
+	switch n.command {
+	case "Optional", "ZeroOrMore":
+		switch n.prelude {
+		case "skip":
+			skip = true
+		case "":
+		default:
+			return nil, n.errorf("unknown prelude %q", n.prelude)
+		}
+	case "Choice":
+	default:
+		if n.prelude != "" {
+			return nil, n.errorf("unknown prelude %q", n.prelude)
+		}
+	}
+
+	switch n.command {
+	case "Terminal":
+		return Terminal(n.text), nil
+	case "NonTerminal":
+		return NonTerminal(n.text), nil
+	case "Comment":
+		return Comment(n.text), nil
+	case "Skip":
+		return Skip(), nil
+	case "Sequence":
+		return Sequence(children...), nil
+	case "Stack":
+		return Stack(children...), nil
+	case "OptionalSequence":
+		return OptionalSequence(children...), nil
+
+	case "Choice":
+		def := 0
+		if n.prelude != "" {
+			var err error
+			if def, err = strconv.Atoi(n.prelude); err != nil {
+				return nil, n.errorf("invalid default %q", n.prelude)
+			}
+		}
+		if def < 0 || def >= len(children) {
+			return nil, n.errorf("default %d out of range", def)
+		}
+		return Choice(def, children...), nil
+
+	case "Optional":
+		if len(children) != 1 {
+			return nil, n.errorf("expected one child, found %d", len(children))
+		}
+		return Optional(children[0], OptionalSkip(skip)), nil
+
+	case "OneOrMore", "ZeroOrMore":
+		if len(children) > 2 {
+			return nil, n.errorf("expected an item and a repeat, found %d children", len(children))
+		}
+		var repeat RailItem
+		if len(children) == 2 {
+			repeat = children[1]
+		}
+		if n.command == "OneOrMore" {
+			return OneOrMore(children[0], OneOrMoreRepeat(repeat)), nil
+		}
+		return ZeroOrMore(children[0], ZeroOrMoreRepeat(repeat), ZeroOrMoreSkip(skip)), nil
+	}
+	panic("unreachable")
+}
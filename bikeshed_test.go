@@ -0,0 +1,68 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testBikeshed = `
+T: /*
+Star skip:
+	N: anything but * followed by /
+T: */
+
+Stack:
+  Or 1:
+    T: +
+    S:
+    T: -
+  Plus:
+    N: digit
+    C: 1-6 times
+  Opt skip:
+    Seq:
+      T: e
+      N: exponent
+  OptSeq:
+    T: a
+    T: b
+`
+
+func TestBikeshed(t *testing.T) {
+	items, err := ReadBikeshed(strings.NewReader(testBikeshed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("got %d items", len(items))
+	}
+	add("bikeshed", Diagram(items...))
+
+	star := items[1].(*choice)
+	if star.def != 0 || star.items[1].(*oneOrMore).item.(*nonTerminal).text != "anything but * followed by /" {
+		t.Fatalf("got star %+v", star)
+	}
+	stack := items[3].(*stack)
+	if len(stack.items) != 4 || stack.items[0].(*choice).def != 1 {
+		t.Fatalf("got stack %+v", stack)
+	}
+	if stack.items[1].(*oneOrMore).rep.(*comment).text != "1-6 times" {
+		t.Fatalf("expected a repeat comment")
+	}
+
+	for _, bad := range []string{
+		"Seq:",
+		"Wat: x",
+		"T: a\n  T: b",
+		"Or 3:\n  T: a",
+		"Or x:\n  T: a",
+		"Opt:\n  T: a\n  T: b",
+		"Opt maybe:\n  T: a",
+		"Seq: a\n  T: b",
+		"just text",
+	} {
+		if _, err := ReadBikeshed(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package railroad
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseCalls parses a diagram written as calls to the constructors of the
+// JavaScript or Python railroad-diagrams libraries, such as
+//
+//	Diagram(Terminal('a'), Choice(0, 'b', NonTerminal('c')))
+//
+// without evaluating it, and returns the items to be passed to Diagram. Bare
+// strings are promoted to Terminals, and options may be given positionally,
+// as Python keyword arguments like skip=True, or as JavaScript option objects
+// like {href: '#c'}. HorizontalChoice is drawn as a Choice, and titles, which
+// this package doesn't draw, are ignored.
+func ParseCalls(src string) ([]RailItem, error) {
+	p := &callParser{src: src}
+	p.next()
+	v := p.value()
+	for p.tok == ';' {
+		p.next()
+	}
+	if p.err == nil && p.tok != 0 {
+		p.errorf("unexpected %q", p.text)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	switch v := v.(type) {
+	case callDiagram:
+		return v, nil
+	case RailItem, string:
+		return []RailItem{callItem(v)}, nil
+	}
+	return nil, fmt.Errorf("calls: expected a diagram, found %v", v)
+}
+
+// callDiagram is the value of a call to Diagram.
+type callDiagram []RailItem
+
+type callParser struct {
+	src   string
+	pos   int
+	start int    // offset of the current token
+	tok   byte   // 'i'dent, 's'tring, 'n'umber, punctuation, or 0 at the end
+	text  string // the token, or the value of a string
+	err   error
+}
+
+func (p *callParser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		line := strings.Count(p.src[:p.start], "\n") + 1
+		col := p.start - strings.LastIndexByte(p.src[:p.start], '\n')
+		p.err = fmt.Errorf("calls: %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+	}
+	p.tok = 0
+}
+
+func (p *callParser) next() {
+	if p.err != nil {
+		return
+	}
+	for p.pos < len(p.src) {
+		switch {
+		case strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0:
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//") || p.src[p.pos] == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				p.start = p.pos
+				p.errorf("unterminated comment")
+				return
+			}
+			p.pos += end + 4
+		default:
+			p.scan()
+			return
+		}
+	}
+	p.start, p.tok, p.text = p.pos, 0, ""
+}
+
+func (p *callParser) scan() {
+	p.start = p.pos
+	c := p.src[p.pos]
+	switch {
+	case isCallIdent(c):
+		for p.pos < len(p.src) && (isCallIdent(p.src[p.pos]) || p.src[p.pos] >= '0' && p.src[p.pos] <= '9') {
+			p.pos++
+		}
+		p.tok, p.text = 'i', p.src[p.start:p.pos]
+
+	case c >= '0' && c <= '9' || c == '-':
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		p.tok, p.text = 'n', p.src[p.start:p.pos]
+
+	case c == '\'' || c == '"':
+		var text strings.Builder
+		for p.pos++; p.pos < len(p.src) && p.src[p.pos] != c; p.pos++ {
+			if p.src[p.pos] == '\n' {
+				break
+			}
+			if p.src[p.pos] != '\\' || p.pos+1 == len(p.src) {
+				text.WriteByte(p.src[p.pos])
+				continue
+			}
+			p.pos++
+			switch esc := p.src[p.pos]; esc {
+			case 'n':
+				text.WriteByte('\n')
+			case 't':
+				text.WriteByte('\t')
+			case 'u', 'x':
+				n := 4
+				if esc == 'x' {
+					n = 2
+				}
+				if p.pos+n >= len(p.src) {
+					p.errorf("invalid escape")
+					return
+				}
+				r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+1+n], 16, 32)
+				if err != nil {
+					p.errorf("invalid escape")
+					return
+				}
+				text.WriteRune(rune(r))
+				p.pos += n
+			default:
+				text.WriteByte(esc)
+			}
+		}
+		if p.pos >= len(p.src) || p.src[p.pos] != c {
+			p.errorf("unterminated string")
+			return
+		}
+		p.pos++
+		p.tok, p.text = 's', text.String()
+
+	case strings.IndexByte("(),=:{}.;", c) >= 0:
+		p.pos++
+		p.tok, p.text = c, p.src[p.start:p.pos]
+
+	default:
+		p.pos++
+		p.errorf("unexpected %q", c)
+	}
+}
+
+// peekByte returns the first byte after the current token that isn't space.
+func (p *callParser) peekByte() byte {
+	if rest := strings.TrimLeft(p.src[p.pos:], " \t\r\n"); rest != "" {
+		return rest[0]
+	}
+	return 0
+}
+
+func (p *callParser) expect(tok byte) {
+	if p.tok != tok {
+		p.errorf("expected %q, found %q", tok, p.text)
+		return
+	}
+	p.next()
+}
+
+// value parses a call, string, number, constant or option object.
+func (p *callParser) value() interface{} {
+	switch p.tok {
+	case 's':
+		text := p.text
+		p.next()
+		return text
+
+	case 'n':
+		n, err := strconv.ParseFloat(p.text, 64)
+		if err != nil {
+			p.errorf("invalid number %q", p.text)
+			return nil
+		}
+		p.next()
+		return n
+
+	case '{':
+		p.next()
+		opts := make(map[string]interface{})
+		for p.tok != '}' && p.err == nil {
+			if p.tok != 'i' && p.tok != 's' {
+				p.errorf("expected an option name, found %q", p.text)
+				return nil
+			}
+			name := p.text
+			p.next()
+			p.expect(':')
+			opts[name] = p.value()
+			if p.tok != ',' {
+				break
+			}
+			p.next()
+		}
+		p.expect('}')
+		return opts
+
+	case 'i':
+		for p.text == "new" && strings.IndexByte("(,.=:)", p.peekByte()) < 0 {
+			p.next()
+		}
+		name := p.text
+		p.next()
+		for p.tok == '.' {
+			p.next()
+			if p.tok != 'i' {
+				p.errorf("expected a name after ., found %q", p.text)
+				return nil
+			}
+			name = p.text
+			p.next()
+		}
+		switch name {
+		case "true", "True":
+			return true
+		case "false", "False":
+			return false
+		case "null", "None", "undefined":
+			return nil
+		}
+
+		start := p.start
+		p.expect('(')
+		var args []interface{}
+		kwargs := make(map[string]interface{})
+		for p.tok != ')' && p.err == nil {
+			if p.tok == 'i' && p.peekByte() == '=' {
+				key := p.text
+				p.next()
+				p.next()
+				kwargs[key] = p.value()
+			} else {
+				args = append(args, p.value())
+			}
+			if p.tok != ',' {
+				break
+			}
+			p.next()
+		}
+		p.expect(')')
+		if p.err != nil {
+			return nil
+		}
+
+		// JavaScript passes options as a trailing object
+		if n := len(args); n > 0 {
+			if opts, ok := args[n-1].(map[string]interface{}); ok {
+				args = args[:n-1]
+				for key, value := range opts {
+					kwargs[key] = value
+				}
+			}
+		}
+
+		v, err := callConstruct(name, args, kwargs)
+		if err != nil {
+			p.start = start
+			p.errorf("%s: %v", name, err)
+			return nil
+		}
+
+		// ignore trailing method calls like .addTo() or .format()
+		for p.tok == '.' && p.err == nil {
+			p.next()
+			p.expect('i')
+			p.expect('(')
+			p.skipArgs()
+			p.expect(')')
+		}
+		return v
+	}
+	p.errorf("unexpected %q", p.text)
+	return nil
+}
+
+// skipArgs skips to the closing parenthesis of a call whose arguments aren't
+// needed, which may be names, strings, numbers, calls and objects.
+func (p *callParser) skipArgs() {
+	for depth := 0; p.tok != 0; p.next() {
+		switch p.tok {
+		case '(', '{':
+			depth++
+		case '}':
+			depth--
+		case ')':
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+	}
+}
+
+// callConstruct calls the constructor with the given arguments.
+func callConstruct(name string, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+	arg := func(i int, key string) interface{} {
+		if v, ok := kwargs[key]; ok {
+			return v
+		}
+		if i >= 0 && i < len(args) {
+			return args[i]
+		}
+		return nil
+	}
+	itemsFrom := func(from int) ([]RailItem, error) {
+		var items []RailItem
+		for i := from; i < len(args); i++ {
+			v := args[i]
+			if !isCallItem(v) {
+				return nil, fmt.Errorf("expected an item, found %v", v)
+			}
+			items = append(items, callItem(v))
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("expected items")
+		}
+		return items, nil
+	}
+	text := func() (string, error) {
+		text, ok := arg(0, "text").(string)
+		if !ok {
+			return "", fmt.Errorf("expected text, found %v", arg(0, "text"))
+		}
+		return text, nil
+	}
+	index := func(i int, key string, n int) (int, error) {
+		def, ok := arg(i, key).(float64)
+		if !ok || def != float64(int(def)) || def < 0 || int(def) >= n {
+			return 0, fmt.Errorf("invalid default %v", arg(i, key))
+		}
+		return int(def), nil
+	}
+	itemArg := func(i int, key string) (RailItem, error) {
+		v := arg(i, key)
+		if !isCallItem(v) {
+			return nil, fmt.Errorf("expected an item, found %v", v)
+		}
+		return callItem(v), nil
+	}
+	skip := func(i int) bool {
+		v := arg(i, "skip")
+		return v == true || v == "skip"
+	}
+
+	switch name {
+	case "Diagram", "ComplexDiagram":
+		var items []RailItem
+		for _, v := range args {
+			switch v.(type) {
+			case callStart:
+			default:
+				if !isCallItem(v) {
+					return nil, fmt.Errorf("expected an item, found %v", v)
+				}
+				items = append(items, callItem(v))
+			}
+		}
+		return callDiagram(items), nil
+
+	case "Start", "End":
+		return callStart{}, nil
+
+	case "Terminal", "NonTerminal", "Comment":
+		text, err := text()
+		if err != nil {
+			return nil, err
+		}
+		href, _ := arg(1, "href").(string)
+		class, _ := arg(3, "cls").(string)
+		switch name {
+		case "Terminal":
+			return Terminal(text, TerminalClass(class)), nil
+		case "NonTerminal":
+			return NonTerminal(text, NonTerminalHref(href)), nil
+		}
+		return Comment(text), nil
+
+	case "Skip":
+		return Skip(), nil
+
+	case "Sequence", "Stack", "OptionalSequence":
+		items, err := itemsFrom(0)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "Stack":
+			return Stack(items...), nil
+		case "OptionalSequence":
+			return OptionalSequence(items...), nil
+		}
+		return Sequence(items...), nil
+
+	case "Choice", "HorizontalChoice":
+		from, def := 1, 0
+		if name == "HorizontalChoice" {
+			from = 0
+		}
+		items, err := itemsFrom(from)
+		if err != nil {
+			return nil, err
+		}
+		if name == "Choice" {
+			if def, err = index(0, "default", len(items)); err != nil {
+				return nil, err
+			}
+		}
+		return Choice(def, items...), nil
+
+	case "MultipleChoice":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("expected a default and type")
+		}
+		items, err := itemsFrom(2)
+		if err != nil {
+			return nil, err
+		}
+		def, err := index(0, "default", len(items))
+		if err != nil {
+			return nil, err
+		}
+		typ := arg(1, "type")
+		if typ != "any" && typ != "all" {
+			return nil, fmt.Errorf("invalid type %v", typ)
+		}
+		return MultipleChoice(def, MultipleChoiceType(typ.(string)), items...), nil
+
+	case "Optional":
+		item, err := itemArg(0, "item")
+		if err != nil {
+			return nil, err
+		}
+		return Optional(item, OptionalSkip(skip(1))), nil
+
+	case "OneOrMore", "ZeroOrMore":
+		item, err := itemArg(0, "item")
+		if err != nil {
+			return nil, err
+		}
+		var repeat RailItem
+		if arg(1, "repeat") != nil {
+			if repeat, err = itemArg(1, "repeat"); err != nil {
+				return nil, err
+			}
+		}
+		if name == "OneOrMore" {
+			return OneOrMore(item, OneOrMoreRepeat(repeat)), nil
+		}
+		return ZeroOrMore(item, ZeroOrMoreRepeat(repeat), ZeroOrMoreSkip(skip(2))), nil
+
+	case "Group":
+		item, err := itemArg(0, "item")
+		if err != nil {
+			return nil, err
+		}
+		var label string
+		switch v := arg(1, "label").(type) {
+		case string:
+			label = v
+		case *comment:
+			label = v.text
+		case nil:
+		default:
+			return nil, fmt.Errorf("invalid label %v", v)
+		}
+		return Group(item, label), nil
+	}
+	return nil, fmt.Errorf("unknown constructor")
+}
+
+// callStart is the value of Start and End, which Diagram adds itself.
+type callStart struct{}
+
+func isCallItem(v interface{}) bool {
+	switch v.(type) {
+	case RailItem, string:
+		return true
+	}
+	return false
+}
+
+// callItem promotes strings to Terminals.
+func callItem(v interface{}) RailItem {
+	if text, ok := v.(string); ok {
+		return Terminal(text)
+	}
+	return v.(RailItem)
+}
+
+func isCallIdent(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
@@ -0,0 +1,92 @@
+package railroad
+
+import "testing"
+
+func TestCalls(t *testing.T) {
+	items, err := ParseCalls(`Diagram(Terminal('a'), Choice(0, 'b', NonTerminal('c')))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items", len(items))
+	}
+	if text := items[1].(*choice).items[0].(*terminal).text; text != "b" {
+		t.Fatalf("expected b to be promoted to a terminal, got %q", text)
+	}
+	add("calls", Diagram(items...))
+
+	items, err = ParseCalls(`
+		# python
+		railroad.Diagram(
+			Start(),
+			Optional('+', skip=True),
+			OneOrMore(NonTerminal("digit"), repeat=Comment('1-6 times')),
+			ZeroOrMore(Sequence(",", 'x'), ",", True),
+			MultipleChoice(1, 'all', '\xe9', "b\u00e9"),
+			Group(Stack('c', OptionalSequence('d', 'e')), label=Comment("group")),
+			End())`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 5 {
+		t.Fatalf("got %d items", len(items))
+	}
+	if items[0].(*choice).def != 0 || items[1].(*oneOrMore).rep.(*comment).text != "1-6 times" {
+		t.Fatalf("got python options wrong")
+	}
+	if choices := items[3].(*multipleChoice).items; choices[0].(*terminal).text != "é" || choices[1].(*terminal).text != "bé" {
+		t.Fatalf("got escapes wrong")
+	}
+	add("python", Diagram(items...))
+
+	items, err = ParseCalls(`
+		// javascript
+		new Diagram(
+			new Optional('+', 'skip'),
+			HorizontalChoice(NonTerminal('x', {href: '#x'}), Terminal('y', {cls: 'char-class'})),
+			ZeroOrMore('a', Comment('sep'), 'skip')
+		).addTo();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[1].(*choice).items[0].(*nonTerminal).href != "#x" {
+		t.Fatalf("expected a link")
+	}
+	if items[2].(*choice).def != 0 {
+		t.Fatalf("expected skip")
+	}
+	add("javascript", Diagram(items...))
+
+	items, err = ParseCalls(`Diagram(Terminal('a')).addTo(document.body)`)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("got %v, %v", items, err)
+	}
+	items, err = ParseCalls(`Diagram('a').format(1, {x: f(2)}, a.b.c).addTo()`)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("got %v, %v", items, err)
+	}
+
+	items, err = ParseCalls(`'bare'`)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("got %v, %v", items, err)
+	}
+
+	for _, bad := range []string{
+		`Diagram(`,
+		`Diagram(Terminal('a')`,
+		`Diagram('a').addTo(document.body`,
+		`Choice(2, 'a')`,
+		`Choice()`,
+		`Wat('a')`,
+		`Sequence(1)`,
+		`MultipleChoice(0, 'some', 'a')`,
+		`Terminal('a`,
+		`Terminal('a') Terminal('b')`,
+		`eval(1)`,
+		`42`,
+	} {
+		if _, err := ParseCalls(bad); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Command railroad renders railroad diagrams for grammars.
+//
+// Usage:
+//
+//	railroad extract [-o output.html] [document]
+//	railroad synopsis [-o output.html] [manpage]
+//	railroad lint [document]
+//
+// The extract command finds the grammars embedded in an HTML or Markdown
+// document, such as <pre class="ebnf"> elements or ```ebnf fenced blocks,
+// and writes an HTML page with a diagram for every production.
+//
+// The synopsis command reads the SYNOPSIS section of a man page, either roff
+// source or formatted text, and writes an HTML page with a diagram for every
+// command it describes.
+//
+// The lint command finds the grammars in a document like extract does and
+// prints the problems with their rules, such as references to undefined rules
+// or rules that can't be reached, exiting with status 1 if there are any.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+
+	"github.com/zeebo/railroad"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "extract":
+		err = run("extract", os.Args[2:], railroad.ExtractProductions)
+	case "synopsis":
+		err = run("synopsis", os.Args[2:], railroad.ReadSynopsis)
+	case "lint":
+		err = lint(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "railroad:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: railroad extract [-o output.html] [document]")
+	fmt.Fprintln(os.Stderr, "       railroad synopsis [-o output.html] [manpage]")
+	fmt.Fprintln(os.Stderr, "       railroad lint [document]")
+	os.Exit(2)
+}
+
+// run reads the productions from the file named by the arguments and writes
+// a page with their diagrams.
+func run(command string, args []string, read func(io.Reader) ([]railroad.Production, error)) error {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	output := fs.String("o", "", "write the page to `file` instead of stdout")
+	fs.Parse(args)
+
+	in, name, err := open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	prods, err := read(in)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return writePage(*output, name, prods)
+}
+
+// lint prints the problems with the grammars in the file named by the
+// arguments, exiting with status 1 if there are any.
+func lint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Parse(args)
+
+	in, name, err := open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	prods, err := railroad.ExtractProductions(in)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	problems := railroad.Lint(railroad.NewGrammar(prods...))
+	for _, problem := range problems {
+		fmt.Printf("%s: %v\n", name, problem)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// open returns the named file, or stdin if name is empty.
+func open(name string) (io.ReadCloser, string, error) {
+	if name == "" {
+		return os.Stdin, "stdin", nil
+	}
+	fh, err := os.Open(name)
+	return fh, name, err
+}
+
+// writePage writes an HTML page with a diagram for every production, linked
+// to each other, to the named file, or stdout if name is empty.
+func writePage(name, title string, prods []railroad.Production) (err error) {
+	var out io.Writer = os.Stdout
+	if name != "" {
+		fh, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := fh.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		out = fh
+	}
+
+	fmt.Fprintf(out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n",
+		html.EscapeString(title))
+	if _, err := railroad.NewGrammar(prods...).WriteTo(out); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, "</body>\n</html>")
+	return err
+}
@@ -0,0 +1,392 @@
+package railroad
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+type DTDOption struct {
+	attributes *bool
+}
+
+// DTDAttributes controls if a production named "<element> attributes" is
+// added after each element with an ATTLIST declaration, summarizing the
+// attributes it may have in any order.
+func DTDAttributes(attributes bool) DTDOption { return DTDOption{attributes: &attributes} }
+
+var dtdEntityRegexp = regexp.MustCompile(`%([\w.:-]+);`)
+
+// ReadDTD reads the element declarations of an XML DTD, producing a diagram
+// of the content model of every element in the order they are declared.
+// Child elements are drawn as NonTerminals, #PCDATA as a Terminal with the
+// "char-class" class, and EMPTY as a Comment. Parameter entities with literal
+// values are expanded.
+func ReadDTD(r io.Reader, options ...DTDOption) ([]Production, error) {
+	var attributes bool
+	for _, opt := range options {
+		if opt.attributes != nil {
+			attributes = *opt.attributes
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	src := string(data)
+
+	var (
+		prods    []Production
+		entities = make(map[string]string)
+		attlists = make(map[string][]RailItem)
+		attlines = make(map[string]int)
+		elements = make(map[string]bool)
+		order    []string
+	)
+
+	expand := func(text string) string {
+		for i := 0; i < 16 && strings.Contains(text, "%"); i++ {
+			text = dtdEntityRegexp.ReplaceAllStringFunc(text, func(ref string) string {
+				if value, ok := entities[ref[1:len(ref)-1]]; ok {
+					return " " + value + " "
+				}
+				return ref
+			})
+		}
+		return text
+	}
+
+	for i := 0; i < len(src); {
+		j := strings.Index(src[i:], "<!")
+		if j < 0 {
+			break
+		}
+		i += j
+		line := strings.Count(src[:i], "\n") + 1
+
+		switch {
+		case strings.HasPrefix(src[i:], "<!--"):
+			end := strings.Index(src[i:], "-->")
+			if end < 0 {
+				return nil, fmt.Errorf("dtd: line %d: unterminated comment", line)
+			}
+			i += end + 3
+			continue
+
+		case strings.HasPrefix(src[i:], "<![IGNORE["):
+			end := strings.Index(src[i:], "]]>")
+			if end < 0 {
+				return nil, fmt.Errorf("dtd: line %d: unterminated section", line)
+			}
+			i += end + 3
+			continue
+
+		case strings.HasPrefix(src[i:], "<!["):
+			// included sections are read as if they weren't there
+			i += 3
+			continue
+		}
+
+		end, ok := dtdDeclEnd(src, i)
+		if !ok {
+			return nil, fmt.Errorf("dtd: line %d: unterminated declaration", line)
+		}
+		decl := src[i+2 : end]
+		i = end + 1
+
+		fields := strings.Fields(decl)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "ENTITY":
+			if len(fields) >= 4 && fields[1] == "%" {
+				rest := decl[strings.IndexByte(decl, '%')+1:]
+				rest = strings.TrimSpace(strings.TrimSpace(rest)[len(fields[2]):])
+				if len(rest) >= 2 && (rest[0] == '"' || rest[0] == '\'') {
+					if k := strings.IndexByte(rest[1:], rest[0]); k >= 0 {
+						entities[fields[2]] = rest[1 : k+1]
+					}
+				}
+			}
+
+		case "ELEMENT":
+			fields = strings.Fields(expand(decl))
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("dtd: line %d: invalid element declaration", line)
+			}
+			name := fields[1]
+			model := strings.Join(fields[2:], " ")
+			item, err := parseDTDModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("dtd: line %d: element %s: %v", line, name, err)
+			}
+			prods = append(prods, Production{Name: name, Item: item, Line: line})
+			elements[name] = true
+
+		case "ATTLIST":
+			if !attributes {
+				continue
+			}
+			fields = dtdFields(expand(decl))
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("dtd: line %d: invalid attribute list", line)
+			}
+			name := fields[1]
+			items, err := parseDTDAttributes(fields[2:])
+			if err != nil {
+				return nil, fmt.Errorf("dtd: line %d: attributes of %s: %v", line, name, err)
+			}
+			if _, ok := attlists[name]; !ok {
+				order = append(order, name)
+				attlines[name] = line
+			}
+			attlists[name] = append(attlists[name], items...)
+		}
+	}
+
+	if !attributes {
+		return prods, nil
+	}
+
+	attrProd := func(name string) Production {
+		items := attlists[name]
+		var item RailItem
+		if len(items) == 1 {
+			item = items[0]
+		} else {
+			item = MultipleChoice(0, MultipleChoiceAll, items...)
+		}
+		return Production{Name: name + " attributes", Item: item, Line: attlines[name]}
+	}
+
+	var out []Production
+	for _, prod := range prods {
+		out = append(out, prod)
+		if len(attlists[prod.Name]) > 0 {
+			out = append(out, attrProd(prod.Name))
+		}
+	}
+	for _, name := range order {
+		if !elements[name] && len(attlists[name]) > 0 {
+			out = append(out, attrProd(name))
+		}
+	}
+	return out, nil
+}
+
+// dtdDeclEnd returns the index of the '>' closing the declaration at i,
+// skipping over quoted literals.
+func dtdDeclEnd(src string, i int) (int, bool) {
+	var quote byte
+	for ; i < len(src); i++ {
+		switch c := src[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// dtdFields splits a declaration into fields, keeping quoted literals and
+// parenthesized enumerations whole.
+func dtdFields(decl string) []string {
+	var fields []string
+	for i := 0; i < len(decl); {
+		switch c := decl[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(decl[i+1:], c)
+			if end < 0 {
+				end = len(decl) - i - 1
+			}
+			fields = append(fields, decl[i:i+end+2])
+			i += end + 2
+		case c == '(':
+			end := strings.IndexByte(decl[i:], ')')
+			if end < 0 {
+				end = len(decl) - i - 1
+			}
+			fields = append(fields, decl[i:i+end+1])
+			i += end + 1
+		default:
+			j := i
+			for j < len(decl) && !strings.ContainsRune(" \t\r\n\"'(", rune(decl[j])) {
+				j++
+			}
+			fields = append(fields, decl[i:j])
+			i = j
+		}
+	}
+	return fields
+}
+
+func parseDTDAttributes(fields []string) ([]RailItem, error) {
+	var items []RailItem
+	for len(fields) > 0 {
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("incomplete attribute definition %q", strings.Join(fields, " "))
+		}
+		name, typ, def := fields[0], fields[1], fields[2]
+		fields = fields[3:]
+
+		var value RailItem
+		switch {
+		case typ == "NOTATION" && len(fields) > 0:
+			value = dtdEnumeration(def)
+			def, fields = fields[0], fields[1:]
+		case strings.HasPrefix(typ, "("):
+			value = dtdEnumeration(typ)
+		default:
+			value = NonTerminal(typ)
+		}
+
+		required := false
+		var note string
+		switch def {
+		case "#REQUIRED":
+			required = true
+		case "#IMPLIED":
+		case "#FIXED":
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("missing value for fixed attribute %s", name)
+			}
+			value = Terminal(strings.Trim(fields[0], `"'`))
+			note, fields = "fixed", fields[1:]
+		default:
+			note = "default " + def
+		}
+
+		attr := []RailItem{Terminal(name), Terminal("="), value}
+		if note != "" {
+			attr = append(attr, Comment(note))
+		}
+		if required {
+			items = append(items, Sequence(attr...))
+		} else {
+			items = append(items, Optional(Sequence(attr...)))
+		}
+	}
+	return items, nil
+}
+
+func dtdEnumeration(list string) RailItem {
+	var items []RailItem
+	for _, value := range strings.Split(strings.Trim(list, "()"), "|") {
+		items = append(items, Terminal(strings.TrimSpace(value)))
+	}
+	return choiceOf(items)
+}
+
+// parseDTDModel parses an element content specification.
+func parseDTDModel(model string) (RailItem, error) {
+	switch strings.TrimSpace(model) {
+	case "EMPTY":
+		return Comment("EMPTY"), nil
+	case "ANY":
+		return ZeroOrMore(Choice(0,
+			Terminal("#PCDATA", TerminalClass("char-class")),
+			NonTerminal("any element"))), nil
+	}
+
+	var toks []string
+	for i := 0; i < len(model); {
+		switch c := model[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case strings.IndexByte("()|,?*+", c) >= 0:
+			toks = append(toks, model[i:i+1])
+			i++
+		default:
+			j := i
+			for j < len(model) && strings.IndexByte(" \t\r\n()|,?*+", model[j]) < 0 {
+				j++
+			}
+			toks = append(toks, model[i:j])
+			i = j
+		}
+	}
+
+	p := &dtdModelParser{toks: toks}
+	item := p.particle()
+	if p.err == nil && len(p.toks) > 0 {
+		p.err = fmt.Errorf("unexpected %q", p.toks[0])
+	}
+	return item, p.err
+}
+
+type dtdModelParser struct {
+	toks []string
+	err  error
+}
+
+func (p *dtdModelParser) peek() string {
+	if len(p.toks) == 0 {
+		return ""
+	}
+	return p.toks[0]
+}
+
+func (p *dtdModelParser) particle() RailItem {
+	var item RailItem
+	switch tok := p.peek(); tok {
+	case "":
+		p.err = fmt.Errorf("unexpected end of content model")
+		return Skip()
+	case "(":
+		p.toks = p.toks[1:]
+		var items []RailItem
+		sep := ""
+		for p.err == nil {
+			items = append(items, p.particle())
+			next := p.peek()
+			if next == ")" {
+				p.toks = p.toks[1:]
+				break
+			}
+			if next != "|" && next != "," || sep != "" && next != sep {
+				p.err = fmt.Errorf("unexpected %q in group", next)
+				return Skip()
+			}
+			sep = next
+			p.toks = p.toks[1:]
+		}
+		if sep == "|" {
+			item = Choice(0, items...)
+		} else {
+			item = sequenceOf(items)
+		}
+	case "#PCDATA":
+		p.toks = p.toks[1:]
+		item = Terminal("#PCDATA", TerminalClass("char-class"))
+	case ")", "|", ",", "?", "*", "+":
+		p.err = fmt.Errorf("unexpected %q", tok)
+		return Skip()
+	default:
+		p.toks = p.toks[1:]
+		item = NonTerminal(tok)
+	}
+
+	switch p.peek() {
+	case "?":
+		item = Optional(item)
+	case "*":
+		item = ZeroOrMore(item)
+	case "+":
+		item = OneOrMore(item)
+	default:
+		return item
+	}
+	p.toks = p.toks[1:]
+	return item
+}
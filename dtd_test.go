@@ -0,0 +1,70 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDTD = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- books, with a <!ELEMENT> in a comment -->
+<!ENTITY % inline "em | code">
+<!ELEMENT book (title, author+, (chapter | appendix)*)>
+<!ATTLIST book
+	id      ID              #REQUIRED
+	lang    CDATA           "en"
+	status  (draft | final) #IMPLIED>
+<!ELEMENT title (#PCDATA | %inline;)*>
+<!ELEMENT author (#PCDATA)>
+<!ELEMENT chapter (title, para+)>
+<![IGNORE[ <!ELEMENT old (#PCDATA)> ]]>
+<!ELEMENT appendix ANY>
+<!ELEMENT br EMPTY>
+<!ATTLIST br clear CDATA #FIXED "none">
+`
+
+func TestDTD(t *testing.T) {
+	prods, err := ReadDTD(strings.NewReader(testDTD), DTDAttributes(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, prod := range prods {
+		names = append(names, prod.Name)
+		add(prod.Name, Diagram(prod.Item))
+	}
+	got := strings.Join(names, ",")
+	if got != "book,book attributes,title,author,chapter,appendix,br,br attributes" {
+		t.Fatalf("got productions %q", got)
+	}
+	if prods[0].Line != 4 {
+		t.Fatalf("got line %d for book", prods[0].Line)
+	}
+	if _, ok := prods[1].Item.(*multipleChoice); !ok {
+		t.Fatalf("expected book attributes to be a MultipleChoice, got %T", prods[1].Item)
+	}
+	title := prods[2].Item.(*choice).items[1].(*oneOrMore).item.(*choice)
+	if len(title.items) != 3 || title.items[2].(*nonTerminal).text != "code" {
+		t.Fatalf("expected the inline entity to be expanded in title")
+	}
+
+	prods, err = ReadDTD(strings.NewReader(testDTD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 6 {
+		t.Fatalf("got %d productions without attributes", len(prods))
+	}
+
+	for _, bad := range []string{
+		`<!ELEMENT a (b | c, d)>`,
+		`<!ELEMENT a (b`,
+		`<!ELEMENT a (b))>`,
+		`<!ELEMENT a>`,
+		`<!-- unterminated`,
+	} {
+		if _, err := ReadDTD(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
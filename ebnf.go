@@ -0,0 +1,367 @@
+package railroad
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// EBNFDialect is the notation EBNF writes items in.
+type EBNFDialect string
+
+const (
+	// EBNFISO is ISO/IEC 14977: a, b | c, [ a ], { a } and (* comments *).
+	EBNFISO EBNFDialect = "iso"
+	// EBNFW3C is the notation of the XML specification: a b | c, a?, a*,
+	// a+ and /* comments */.
+	EBNFW3C EBNFDialect = "w3c"
+	// EBNFABNF is the ABNF of RFC 5234: a b / c, [a], *a, 1*a, with
+	// terminals written as case-sensitive %s"strings" from RFC 7405 and
+	// comments written as <prose>.
+	EBNFABNF EBNFDialect = "abnf"
+)
+
+// the binding strength of an expression, to decide when to parenthesize it.
+const (
+	ebnfChoice = iota
+	ebnfSequence
+	ebnfAtom
+)
+
+// EBNF returns the item as an expression in the dialect. Optional and
+// ZeroOrMore are recognized by the Choice of Skip they are built from,
+// OptionalSequence is expanded into the choices it allows, and Comments,
+// the repeats of MultipleChoice and the labels of Groups are written as
+// comments.
+func EBNF(item RailItem, dialect EBNFDialect) (string, error) {
+	f := &ebnfFormatter{dialect: dialect}
+	text, _ := f.expr(item)
+	if f.err != nil {
+		return "", f.err
+	}
+	return text, nil
+}
+
+// WriteEBNF writes a rule for every production in the dialect. A rule whose
+// alternatives don't fit on a line is written with one alternative per line.
+func WriteEBNF(w io.Writer, dialect EBNFDialect, prods ...Production) error {
+	f := &ebnfFormatter{dialect: dialect}
+	for _, prod := range prods {
+		name := f.name(prod.Name)
+		define, end := " = ", ""
+		switch dialect {
+		case EBNFISO:
+			end = " ;"
+		case EBNFW3C:
+			define = " ::= "
+		}
+
+		body, _ := f.expr(prod.Item)
+		if ch, ok := prod.Item.(*choice); ok && len(name)+len(body) > 72 && !hasSkip(ch.items) {
+			alts := make([]string, len(ch.items))
+			for i, alt := range ch.items {
+				alts[i], _ = f.expr(alt)
+			}
+			indent := strings.Repeat(" ", len(name)+len(define)-2)
+			body = strings.Join(alts, "\n"+indent+strings.TrimLeft(f.or(), " "))
+		}
+		if f.err != nil {
+			return fmt.Errorf("%s: %v", prod.Name, f.err)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s%s%s\n", name, define, body, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ebnfFormatter struct {
+	dialect EBNFDialect
+	err     error // the first item that couldn't be written
+}
+
+// expr returns the item as an expression and how strongly it binds.
+func (f *ebnfFormatter) expr(item RailItem) (string, int) {
+	switch item := item.(type) {
+	case *skip:
+		switch f.dialect {
+		case EBNFW3C:
+			return "()", ebnfAtom
+		case EBNFABNF:
+			return `""`, ebnfAtom
+		}
+		return "", ebnfAtom
+
+	case *terminal:
+		return f.quote(item.text)
+
+	case *nonTerminal:
+		return f.name(item.text), ebnfAtom
+
+	case *comment:
+		// outside of ABNF a comment is whitespace, so it can't be an operand
+		if f.dialect == EBNFABNF {
+			return f.comment(item.text), ebnfAtom
+		}
+		return f.comment(item.text), ebnfSequence
+
+	case *sequence:
+		return f.sequence(item.items)
+
+	case *stack:
+		return f.sequence(item.items)
+
+	case *optionalSequence:
+		// at least one of the items, in order
+		alts := make([]string, len(item.items))
+		for i := range item.items {
+			parts := []string{f.wrap(item.items[i], ebnfSequence)}
+			for _, rest := range item.items[i+1:] {
+				text, _ := f.optional(f.expr(rest))
+				parts = append(parts, text)
+			}
+			alts[i] = strings.Join(parts, f.then())
+		}
+		return strings.Join(alts, f.or()), ebnfChoice
+
+	case *choice:
+		var alts []RailItem
+		for _, alt := range item.items {
+			if _, ok := alt.(*skip); !ok {
+				alts = append(alts, alt)
+			}
+		}
+		if len(alts) < len(item.items) {
+			if len(alts) == 1 {
+				if rep, ok := alts[0].(*oneOrMore); ok {
+					return f.repeat(rep, false)
+				}
+				return f.optional(f.expr(alts[0]))
+			}
+			if len(alts) > 1 {
+				return f.optional(f.choice(alts))
+			}
+			return f.expr(item.items[0])
+		}
+		return f.choice(alts)
+
+	case *multipleChoice:
+		note := "each at most once, in any order"
+		if MultipleChoiceType(item.type_) == MultipleChoiceAll {
+			note = "each exactly once, in any order"
+		}
+		text, _ := f.plus(f.choice(item.items))
+		return text + " " + f.comment(note), ebnfSequence
+
+	case *oneOrMore:
+		return f.repeat(item, true)
+
+	case *group:
+		if item.label == nil {
+			return f.expr(item.item)
+		}
+		return f.wrap(item.item, ebnfSequence) + " " + f.comment(item.label.(*comment).text), ebnfSequence
+	}
+	if f.err == nil {
+		f.err = fmt.Errorf("ebnf: unknown item %T", item)
+	}
+	return "", ebnfAtom
+}
+
+// wrap returns the item as an expression, parenthesized if it binds less
+// strongly than prec.
+func (f *ebnfFormatter) wrap(item RailItem, prec int) string {
+	text, p := f.expr(item)
+	if p < prec {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func (f *ebnfFormatter) sequence(items []RailItem) (string, int) {
+	var parts []string
+	var last RailItem = Skip()
+	for _, item := range items {
+		if _, ok := item.(*skip); !ok {
+			parts = append(parts, f.wrap(item, ebnfSequence))
+			last = item
+		}
+	}
+	if len(parts) < 2 {
+		return f.expr(last)
+	}
+	return strings.Join(parts, f.then()), ebnfSequence
+}
+
+func (f *ebnfFormatter) choice(items []RailItem) (string, int) {
+	if len(items) == 1 {
+		return f.expr(items[0])
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i], _ = f.expr(item)
+	}
+	return strings.Join(parts, f.or()), ebnfChoice
+}
+
+// repeat returns the repetition of a OneOrMore, or of the ZeroOrMore it is
+// part of if one is false. A repeat that is only a Comment is written after
+// the repetition.
+func (f *ebnfFormatter) repeat(item *oneOrMore, one bool) (string, int) {
+	note := ""
+	sep := item.rep
+	if c, ok := sep.(*comment); ok {
+		note, sep = " "+f.comment(c.text), Skip()
+	}
+
+	text, prec := f.expr(item.item)
+	if _, ok := sep.(*skip); ok {
+		if one {
+			text, prec = f.plus(text, prec)
+		} else {
+			text, prec = f.star(text, prec)
+		}
+		if note != "" {
+			return text + note, ebnfSequence
+		}
+		return text, prec
+	}
+
+	rest := f.wrap(sep, ebnfSequence) + f.then() + f.wrap(item.item, ebnfSequence)
+	rest, _ = f.star(rest, ebnfSequence)
+	text = f.wrap(item.item, ebnfSequence) + f.then() + rest
+	if one {
+		return text, ebnfSequence
+	}
+	return f.optional(text, ebnfSequence)
+}
+
+func (f *ebnfFormatter) optional(text string, prec int) (string, int) {
+	switch f.dialect {
+	case EBNFISO:
+		return "[ " + text + " ]", ebnfAtom
+	case EBNFABNF:
+		return "[" + text + "]", ebnfAtom
+	}
+	return f.paren(text, prec) + "?", ebnfAtom
+}
+
+func (f *ebnfFormatter) star(text string, prec int) (string, int) {
+	switch f.dialect {
+	case EBNFISO:
+		return "{ " + text + " }", ebnfAtom
+	case EBNFABNF:
+		return "*" + f.paren(text, prec), ebnfAtom
+	}
+	return f.paren(text, prec) + "*", ebnfAtom
+}
+
+func (f *ebnfFormatter) plus(text string, prec int) (string, int) {
+	switch f.dialect {
+	case EBNFISO:
+		if prec < ebnfSequence {
+			text = "(" + text + ")"
+		}
+		return text + ", { " + text + " }", ebnfSequence
+	case EBNFABNF:
+		return "1*" + f.paren(text, prec), ebnfAtom
+	}
+	return f.paren(text, prec) + "+", ebnfAtom
+}
+
+// paren parenthesizes the text if it isn't an atom.
+func (f *ebnfFormatter) paren(text string, prec int) string {
+	if prec < ebnfAtom {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func (f *ebnfFormatter) then() string {
+	if f.dialect == EBNFISO {
+		return ", "
+	}
+	return " "
+}
+
+func (f *ebnfFormatter) or() string {
+	if f.dialect == EBNFABNF {
+		return " / "
+	}
+	return " | "
+}
+
+func (f *ebnfFormatter) comment(text string) string {
+	switch f.dialect {
+	case EBNFISO:
+		return "(* " + strings.Replace(text, "*)", "* )", -1) + " *)"
+	case EBNFABNF:
+		return "<" + strings.Replace(text, ">", "", -1) + ">"
+	}
+	return "/* " + strings.Replace(text, "*/", "* /", -1) + " */"
+}
+
+// quote returns the text as a terminal, split into several if it contains
+// both kinds of quotes, or as character codes in ABNF, whose strings can't
+// contain a double quote. ABNF strings are case-insensitive unless marked
+// with %s.
+func (f *ebnfFormatter) quote(text string) (string, int) {
+	if f.dialect == EBNFABNF {
+		if strings.ContainsAny(text, "\"") || strings.IndexFunc(text, notPrintable) >= 0 {
+			var codes []string
+			for _, r := range text {
+				codes = append(codes, fmt.Sprintf("%02X", r))
+			}
+			return "%x" + strings.Join(codes, "."), ebnfAtom
+		}
+		if strings.IndexFunc(text, unicode.IsLetter) >= 0 {
+			return `%s"` + text + `"`, ebnfAtom
+		}
+		return `"` + text + `"`, ebnfAtom
+	}
+
+	if !strings.Contains(text, `"`) {
+		return `"` + text + `"`, ebnfAtom
+	}
+	if !strings.Contains(text, `'`) {
+		return `'` + text + `'`, ebnfAtom
+	}
+	var parts []string
+	for i, part := range strings.Split(text, `"`) {
+		if i > 0 {
+			parts = append(parts, `'"'`)
+		}
+		if part != "" {
+			parts = append(parts, `"`+part+`"`)
+		}
+	}
+	return strings.Join(parts, f.then()), ebnfSequence
+}
+
+func notPrintable(r rune) bool { return r < ' ' || r > '~' }
+
+// name returns the text as a rule name, with the characters the dialect
+// doesn't allow in names turned into separators.
+func (f *ebnfFormatter) name(text string) string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		if r == '_' {
+			return f.dialect != EBNFW3C
+		}
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if f.dialect == EBNFISO {
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(fields, "-")
+}
+
+func hasSkip(items []RailItem) bool {
+	for _, item := range items {
+		if _, ok := item.(*skip); ok {
+			return true
+		}
+	}
+	return false
+}
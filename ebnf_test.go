@@ -0,0 +1,68 @@
+package railroad
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEBNF(t *testing.T) {
+	item := func() RailItem {
+		return Sequence(
+			Terminal("{"),
+			Optional(Choice(0, NonTerminal("a"), NonTerminal("b_c"))),
+			ZeroOrMore(NonTerminal("member"), ZeroOrMoreRepeat(Terminal(","))),
+			OneOrMore(Terminal(`say "it's"`)),
+			ZeroOrMore(Comment("comment"), ZeroOrMoreRepeat(Comment("1-6 times"))),
+			Terminal("}"),
+		)
+	}
+
+	for _, test := range []struct {
+		dialect EBNFDialect
+		want    string
+	}{
+		{EBNFISO, `"{", [ a | b c ], [ member, { ",", member } ], "say ", '"', "it's", '"', { "say ", '"', "it's", '"' }, { (* comment *) } (* 1-6 times *), "}"`},
+		{EBNFW3C, `"{" (a | b_c)? (member ("," member)*)? ("say " '"' "it's" '"')+ (/* comment */)* /* 1-6 times */ "}"`},
+		{EBNFABNF, `"{" [a / b-c] [member *("," member)] 1*%x73.61.79.20.22.69.74.27.73.22 *<comment> <1-6 times> "}"`},
+	} {
+		if got, _ := EBNF(item(), test.dialect); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.dialect, got, test.want)
+		}
+	}
+
+	if got, _ := EBNF(OptionalSequence(Terminal("a"), Terminal("b"), Terminal("c")), EBNFW3C); got != `"a" "b"? "c"? | "b" "c"? | "c"` {
+		t.Errorf("got %s", got)
+	}
+	if got, _ := EBNF(Group(Choice(0, Terminal("a"), Terminal("b")), "label"), EBNFW3C); got != `("a" | "b") /* label */` {
+		t.Errorf("got %s", got)
+	}
+	if got, _ := EBNF(MultipleChoice(0, MultipleChoiceAll, Terminal("a"), Terminal("b")), EBNFABNF); got != `1*(%s"a" / %s"b") <each exactly once, in any order>` {
+		t.Errorf("got %s", got)
+	}
+
+	if got, _ := EBNF(Sequence(Terminal("é"), Terminal("-"), Terminal("a\tb")), EBNFABNF); got != `%xE9 "-" %x61.09.62` {
+		t.Errorf("got %s", got)
+	}
+	if _, err := EBNF(Sequence(Terminal("a"), newStart()), EBNFW3C); err == nil {
+		t.Error("expected an error for an unknown item")
+	}
+
+	var buf bytes.Buffer
+	err := WriteEBNF(&buf, EBNFISO,
+		Production{Name: "short", Item: Choice(0, Terminal("a"), Skip())},
+		Production{Name: "long rule", Item: Choice(0,
+			Sequence(NonTerminal("first alternative"), NonTerminal("with a sequence")),
+			Sequence(NonTerminal("second alternative"), NonTerminal("with a sequence")),
+		)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `short = [ "a" ] ;
+long rule = first alternative, with a sequence
+          | second alternative, with a sequence ;
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
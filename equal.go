@@ -0,0 +1,73 @@
+package railroad
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Equal reports whether the items are the same tree: the same kinds, with the
+// same text, options and children.
+func Equal(x, y RailItem) bool {
+	if KindOf(x) != KindOf(y) || KindOf(x) == "" || TextOf(x) != TextOf(y) {
+		return false
+	}
+	switch x := x.(type) {
+	case *terminal:
+		if x.class != y.(*terminal).class {
+			return false
+		}
+	case *nonTerminal:
+		if x.href != y.(*nonTerminal).href {
+			return false
+		}
+	case *choice:
+		if x.def != y.(*choice).def {
+			return false
+		}
+	case *multipleChoice:
+		if x.def != y.(*multipleChoice).def || x.type_ != y.(*multipleChoice).type_ {
+			return false
+		}
+	}
+
+	xs, ys := Children(x), Children(y)
+	if len(xs) != len(ys) {
+		return false
+	}
+	for i := range xs {
+		if !Equal(xs[i], ys[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a hash of the item that is the same for Equal items.
+func Hash(item RailItem) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(Dump(item)))
+	return h.Sum64()
+}
+
+// Dump returns the item as the calls of the constructors that build it, such
+// as Sequence(Terminal("{"), ZeroOrMore(NonTerminal("value")), Terminal("}")),
+// with the arguments of long calls on indented lines.
+func Dump(item RailItem) string {
+	text, err := goPrinter{width: 100, indent: "\t"}.expr(item)
+	if err != nil {
+		return fmt.Sprintf("%T", item)
+	}
+	return text
+}
+
+func (self *skip) String() string             { return Dump(self) }
+func (self *terminal) String() string         { return Dump(self) }
+func (self *nonTerminal) String() string      { return Dump(self) }
+func (self *comment) String() string          { return Dump(self) }
+func (self *sequence) String() string         { return Dump(self) }
+func (self *stack) String() string            { return Dump(self) }
+func (self *optionalSequence) String() string { return Dump(self) }
+func (self *choice) String() string           { return Dump(self) }
+func (self *multipleChoice) String() string   { return Dump(self) }
+func (self *oneOrMore) String() string        { return Dump(self) }
+func (self *group) String() string            { return Dump(self) }
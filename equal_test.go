@@ -0,0 +1,51 @@
+package railroad
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	item := func(name string) RailItem {
+		return Sequence(
+			Terminal("{"),
+			ZeroOrMore(NonTerminal(name)),
+			Terminal("}"),
+		)
+	}
+
+	if !Equal(item("value"), item("value")) || Hash(item("value")) != Hash(item("value")) {
+		t.Fatal("expected equal items")
+	}
+	if Equal(item("value"), item("other")) || Hash(item("value")) == Hash(item("other")) {
+		t.Fatal("expected different items")
+	}
+	for _, pair := range [][2]RailItem{
+		{Terminal("a"), Terminal("a", TerminalClass("c"))},
+		{NonTerminal("a"), NonTerminal("a", NonTerminalHref("#a"))},
+		{Terminal("a"), NonTerminal("a")},
+		{Choice(0, Terminal("a"), Terminal("b")), Choice(1, Terminal("a"), Terminal("b"))},
+		{MultipleChoice(0, MultipleChoiceAny, Skip()), MultipleChoice(0, MultipleChoiceAll, Skip())},
+		{Group(Skip(), "a"), Group(Skip(), "b")},
+		{Sequence(Skip()), Sequence(Skip(), Skip())},
+		{OneOrMore(Skip()), OneOrMore(Skip(), OneOrMoreRepeat(Comment("a")))},
+	} {
+		if Equal(pair[0], pair[1]) {
+			t.Fatalf("expected %v and %v to differ", pair[0], pair[1])
+		}
+	}
+	if !Equal(Optional(Terminal("a")), Choice(1, Skip(), Terminal("a"))) {
+		t.Fatal("expected Optional to equal its Choice")
+	}
+
+	if got := fmt.Sprint(item("Component value")); got != `Sequence(Terminal("{"), ZeroOrMore(NonTerminal("Component value")), Terminal("}"))` {
+		t.Fatalf("got %s", got)
+	}
+	want := `Stack(
+	Sequence(Terminal("{"), ZeroOrMore(NonTerminal("Component value")), Terminal("}")),
+	Choice(0, Comment("a long enough comment to wrap the choice"), Terminal("x")),
+)`
+	if got := Dump(Stack(item("Component value"), Choice(0, Comment("a long enough comment to wrap the choice"), Terminal("x")))); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
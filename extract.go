@@ -0,0 +1,130 @@
+package railroad
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dialects maps the names grammar blocks are marked with, such as the class
+// of a <pre> element or the language of a fenced code block, to the reader
+// for that grammar format.
+var Dialects = map[string]func(io.Reader) ([]Production, error){
+	"dtd":   readDTD,
+	"ebnf":  ReadGoEBNF,
+	"gbnf":  ReadGBNF,
+	"yacc":  readYacc,
+	"bison": readYacc,
+}
+
+func readDTD(r io.Reader) ([]Production, error)  { return ReadDTD(r) }
+func readYacc(r io.Reader) ([]Production, error) { return ReadYacc(r) }
+
+// Block is a grammar embedded in a document.
+type Block struct {
+	Dialect string
+	Text    string
+	Line    int // line of the document Text starts on
+}
+
+var (
+	preRegexp   = regexp.MustCompile(`(?is)<pre\b([^>]*)>(.*?)</pre\s*>`)
+	classRegexp = regexp.MustCompile(`(?i)\bclass\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	tagRegexp   = regexp.MustCompile(`(?s)<[^>]*>`)
+	fenceRegexp = regexp.MustCompile("^ {0,3}(```+|~~~+)[ \t]*([^\\s`]*)")
+)
+
+// ExtractBlocks finds the grammars embedded in an HTML or Markdown document,
+// in the order they appear. Grammars are <pre> elements with a class naming
+// one of the Dialects, like the Go specification's <pre class="ebnf">, and
+// fenced code blocks whose language is one of the Dialects.
+func ExtractBlocks(doc string) []Block {
+	var blocks []Block
+	lineAt := func(offset int) int { return strings.Count(doc[:offset], "\n") + 1 }
+
+	var pres [][]int
+	for _, m := range preRegexp.FindAllStringSubmatchIndex(doc, -1) {
+		pres = append(pres, m)
+		attrs := doc[m[2]:m[3]]
+		dialect := ""
+		if c := classRegexp.FindStringSubmatch(attrs); c != nil {
+			for _, class := range strings.Fields(c[1] + c[2] + c[3]) {
+				if _, ok := Dialects[class]; ok {
+					dialect = class
+					break
+				}
+			}
+		}
+		if dialect == "" {
+			continue
+		}
+		text := html.UnescapeString(tagRegexp.ReplaceAllString(doc[m[4]:m[5]], ""))
+		blocks = append(blocks, Block{Dialect: dialect, Text: text, Line: lineAt(m[4])})
+	}
+
+	// fences inside <pre> elements are part of their text
+	lines := strings.SplitAfter(doc, "\n")
+	inPre := func(line int) bool {
+		offset := len(strings.Join(lines[:line], ""))
+		for _, m := range pres {
+			if m[0] <= offset && offset < m[1] {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(lines); i++ {
+		m := fenceRegexp.FindStringSubmatch(lines[i])
+		if m == nil || inPre(i) {
+			continue
+		}
+		fence, dialect := m[1], m[2]
+		start := i + 1
+		for i++; i < len(lines); i++ {
+			if strings.HasPrefix(strings.TrimLeft(lines[i], " "), fence) &&
+				strings.Trim(strings.TrimSpace(lines[i]), fence[:1]) == "" {
+				break
+			}
+		}
+		if _, ok := Dialects[dialect]; !ok {
+			continue
+		}
+		blocks = append(blocks, Block{
+			Dialect: dialect,
+			Text:    strings.Join(lines[start:i], ""),
+			Line:    start + 1,
+		})
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Line < blocks[j].Line })
+	return blocks
+}
+
+// ExtractProductions reads every grammar embedded in an HTML or Markdown
+// document as found by ExtractBlocks. The lines of the productions are
+// lines of the document.
+func ExtractProductions(r io.Reader) ([]Production, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var prods []Production
+	for _, block := range ExtractBlocks(string(data)) {
+		bprods, err := Dialects[block.Dialect](strings.NewReader(block.Text))
+		if err != nil {
+			return nil, fmt.Errorf("%s block on line %d: %v", block.Dialect, block.Line, err)
+		}
+		for _, prod := range bprods {
+			if prod.Line > 0 {
+				prod.Line += block.Line - 1
+			}
+			prods = append(prods, prod)
+		}
+	}
+	return prods, nil
+}
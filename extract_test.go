@@ -0,0 +1,66 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testExtractHTML = `<html>
+<p>A term is</p>
+<pre class="ebnf">
+Term = <a href="#Name">Name</a> | "&lt;" Term "&gt;" .
+</pre>
+<pre class="go">x := 1</pre>
+<pre class="grammar ebnf">Name = "a" … "z" .</pre>
+</html>
+`
+
+const testExtractMarkdown = "# Spec\n" +
+	"\n" +
+	"```go\n" +
+	"x = 1 .\n" +
+	"```\n" +
+	"\n" +
+	"```ebnf\n" +
+	"List = Item { \",\" Item } .\n" +
+	"\n" +
+	"Item = \"x\" .\n" +
+	"```\n"
+
+func TestExtract(t *testing.T) {
+	blocks := ExtractBlocks(testExtractHTML)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d html blocks", len(blocks))
+	}
+	if blocks[0].Line != 3 || !strings.Contains(blocks[0].Text, `"<" Term ">"`) {
+		t.Fatalf("got block %+v", blocks[0])
+	}
+
+	prods, err := ExtractProductions(strings.NewReader(testExtractHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 2 || prods[0].Name != "Term" || prods[0].Line != 4 || prods[1].Name != "Name" {
+		t.Fatalf("got html productions %+v", prods)
+	}
+
+	prods, err = ExtractProductions(strings.NewReader(testExtractMarkdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 2 || prods[0].Name != "List" || prods[0].Line != 8 || prods[1].Line != 10 {
+		t.Fatalf("got markdown productions %+v", prods)
+	}
+	for _, prod := range prods {
+		add(prod.Name, Diagram(prod.Item))
+	}
+
+	blocks = ExtractBlocks("<pre class=\"ebnf\">\n```ebnf\nA = \"a\" .\n```\n</pre>\n")
+	if len(blocks) != 1 || blocks[0].Line != 1 {
+		t.Fatalf("got blocks %+v for a fence inside <pre>", blocks)
+	}
+
+	if _, err := ExtractProductions(strings.NewReader("```ebnf\nbroken\n```\n")); err == nil {
+		t.Fatal("expected an error for an invalid block")
+	}
+}
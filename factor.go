@@ -0,0 +1,121 @@
+package railroad
+
+// Factor returns a copy of the item with the items that alternatives of a
+// Choice start or end with moved out of it, so that
+//
+//	Choice(0, Sequence(Terminal("SELECT"), a), Sequence(Terminal("SELECT"), b), c)
+//
+// becomes
+//
+//	Choice(0, Sequence(Terminal("SELECT"), Choice(0, a, b)), c)
+//
+// Alternatives sharing a first item are gathered where the first of them was,
+// and an alternative left empty becomes a Skip, so the Choice matches the same
+// sequences as before. Common prefixes are factored before common suffixes,
+// and the Choices left are simplified as by Simplify.
+func Factor(item RailItem) RailItem {
+	return Rewrite(item, func(item RailItem) RailItem {
+		if ch, ok := item.(*choice); ok {
+			return factorBoth(ch.items, ch.def)
+		}
+		return item
+	})
+}
+
+// factorBoth returns the Choice of the alternatives with their common
+// prefixes and then their common suffixes factored out.
+func factorBoth(alts []RailItem, def int) RailItem {
+	item := factorChoice(alts, def, false)
+	if ch, ok := item.(*choice); ok {
+		item = factorChoice(ch.items, ch.def, true)
+	}
+	return item
+}
+
+// factorChoice returns the Choice of the alternatives with their common
+// prefixes, or suffixes if suffix is set, factored out.
+func factorChoice(alts []RailItem, def int, suffix bool) RailItem {
+	branches := make([][]RailItem, len(alts))
+	for i, alt := range alts {
+		branches[i] = branchItems(alt)
+	}
+	end := func(branch []RailItem, n int) RailItem {
+		if suffix {
+			return branch[len(branch)-1-n]
+		}
+		return branch[n]
+	}
+
+	// gather the alternatives by their first item, or last if suffix
+	var groups [][]int
+outer:
+	for i, branch := range branches {
+		if len(branch) > 0 {
+			for g, group := range groups {
+				first := branches[group[0]]
+				if len(first) > 0 && Equal(end(first, 0), end(branch, 0)) {
+					groups[g] = append(group, i)
+					continue outer
+				}
+			}
+		}
+		groups = append(groups, []int{i})
+	}
+
+	var out []RailItem
+	newDef := 0
+	for _, group := range groups {
+		restDef := 0
+		for j, i := range group {
+			if i == def {
+				newDef, restDef = len(out), j
+			}
+		}
+		if len(group) == 1 {
+			out = append(out, alts[group[0]])
+			continue
+		}
+
+		// the length of the prefix or suffix shared by the group
+		first := branches[group[0]]
+		n := 1
+	grow:
+		for ; n < len(first); n++ {
+			for _, i := range group[1:] {
+				if n >= len(branches[i]) || !Equal(end(first, n), end(branches[i], n)) {
+					break grow
+				}
+			}
+		}
+
+		rest := make([]RailItem, len(group))
+		for j, i := range group {
+			if suffix {
+				rest[j] = sequenceOf(branches[i][:len(branches[i])-n])
+			} else {
+				rest[j] = sequenceOf(branches[i][n:])
+			}
+		}
+		inner := factorBoth(rest, restDef)
+
+		var items []RailItem
+		if suffix {
+			items = append(append(items, branchItems(inner)...), first[len(first)-n:]...)
+		} else {
+			items = append(first[:n:n], branchItems(inner)...)
+		}
+		out = append(out, sequenceOf(items))
+	}
+	return simplifyChoice(Choice(newDef, out...).(*choice))
+}
+
+// branchItems returns the items an alternative is a sequence of.
+func branchItems(item RailItem) []RailItem {
+	switch item := item.(type) {
+	case *sequence:
+		return item.items
+	case *skip:
+		return nil
+	}
+	return []RailItem{item}
+}
@@ -0,0 +1,61 @@
+package railroad
+
+import "testing"
+
+func TestFactor(t *testing.T) {
+	for _, test := range []struct {
+		in, want RailItem
+	}{
+		{
+			Choice(0,
+				Sequence(Terminal("SELECT"), Terminal("a")),
+				Sequence(Terminal("SELECT"), Terminal("b")),
+				Terminal("c")),
+			Choice(0,
+				Sequence(Terminal("SELECT"), Choice(0, Terminal("a"), Terminal("b"))),
+				Terminal("c")),
+		},
+		{
+			Choice(2,
+				Terminal("c"),
+				Sequence(Terminal("a"), Terminal("x")),
+				Sequence(Terminal("b"), Terminal("x"))),
+			Choice(1,
+				Terminal("c"),
+				Sequence(Choice(1, Terminal("a"), Terminal("b")), Terminal("x"))),
+		},
+		{
+			Choice(0,
+				Terminal("SELECT"),
+				Sequence(Terminal("SELECT"), Terminal("DISTINCT"))),
+			Sequence(Terminal("SELECT"), Optional(Terminal("DISTINCT"), OptionalSkip(true))),
+		},
+		{
+			Choice(0,
+				Sequence(Terminal("a"), Terminal("b"), Terminal("x")),
+				Sequence(Terminal("a"), Terminal("b"), Terminal("y")),
+				Sequence(Terminal("a"), Terminal("c"), Terminal("z"))),
+			Sequence(Terminal("a"), Choice(0,
+				Sequence(Terminal("b"), Choice(0, Terminal("x"), Terminal("y"))),
+				Sequence(Terminal("c"), Terminal("z")))),
+		},
+		{
+			Choice(0,
+				Sequence(Terminal("a"), Terminal("m"), Terminal("z")),
+				Sequence(Terminal("a"), Terminal("n"), Terminal("z"))),
+			Sequence(Terminal("a"), Choice(0, Terminal("m"), Terminal("n")), Terminal("z")),
+		},
+		{Choice(0, Terminal("a"), Terminal("a")), Terminal("a")},
+		{Optional(Sequence(Terminal("b"), Terminal("c"))), Optional(Sequence(Terminal("b"), Terminal("c")))},
+	} {
+		if got := Factor(test.in); !Equal(got, test.want) {
+			t.Errorf("Factor(%v):\ngot  %v\nwant %v", test.in, got, test.want)
+		}
+	}
+
+	add("factor", Diagram(Factor(Choice(0,
+		Sequence(Terminal("SELECT"), NonTerminal("columns"), Terminal("FROM"), NonTerminal("table")),
+		Sequence(Terminal("SELECT"), Terminal("*"), Terminal("FROM"), NonTerminal("table")),
+		Sequence(Terminal("SELECT"), Terminal("COUNT(*)")),
+	))))
+}
@@ -0,0 +1,115 @@
+package railroad
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type FlagsOption struct {
+	args     []string
+	anyOrder *bool
+}
+
+// FlagsArgs declares the positional arguments that follow the flags. An
+// argument ending in "..." may be repeated, and one wrapped in brackets like
+// "[file]" is optional.
+func FlagsArgs(args ...string) FlagsOption { return FlagsOption{args: args} }
+
+// FlagsAnyOrder controls if the flags are drawn as a MultipleChoice taken
+// once each in any order rather than a ZeroOrMore of a Choice.
+func FlagsAnyOrder(anyOrder bool) FlagsOption { return FlagsOption{anyOrder: &anyOrder} }
+
+// Flags returns a diagram of the command line accepted by the flag set: its
+// name, the flags, and then any positional arguments. Flags that take a value
+// are followed by a NonTerminal for the value, named as flag.PrintDefaults
+// would name it, and every flag is followed by a Comment with its usage and
+// default.
+func Flags(fs *flag.FlagSet, options ...FlagsOption) RailItem {
+	var (
+		args     []string
+		anyOrder bool
+	)
+	for _, opt := range options {
+		args = append(args, opt.args...)
+		if opt.anyOrder != nil {
+			anyOrder = *opt.anyOrder
+		}
+	}
+
+	var flags []RailItem
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		item := []RailItem{Terminal("-" + f.Name)}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			item = append(item, NonTerminal(name))
+		}
+		if !isZeroFlag(f) {
+			if g, ok := f.Value.(flag.Getter); ok && isString(g.Get()) {
+				usage += fmt.Sprintf(" (default %q)", f.DefValue)
+			} else {
+				usage += fmt.Sprintf(" (default %v)", f.DefValue)
+			}
+		}
+		if usage != "" {
+			item = append(item, Comment(usage))
+		}
+		flags = append(flags, sequenceOf(item))
+	})
+
+	var items []RailItem
+	if fs.Name() != "" {
+		items = append(items, Terminal(fs.Name()))
+	}
+	switch {
+	case len(flags) == 0:
+	case anyOrder && len(flags) > 1:
+		items = append(items, Optional(MultipleChoice(0, MultipleChoiceAny, flags...)))
+	case anyOrder:
+		items = append(items, Optional(flags[0]))
+	default:
+		items = append(items, ZeroOrMore(choiceOf(flags)))
+	}
+	for _, arg := range args {
+		optional := strings.HasPrefix(arg, "[") && strings.HasSuffix(arg, "]")
+		if optional {
+			arg = arg[1 : len(arg)-1]
+		}
+		repeated := strings.HasSuffix(arg, "...")
+		item := NonTerminal(strings.TrimSuffix(arg, "..."))
+		switch {
+		case optional && repeated:
+			item = ZeroOrMore(item)
+		case optional:
+			item = Optional(item)
+		case repeated:
+			item = OneOrMore(item)
+		}
+		items = append(items, item)
+	}
+	return sequenceOf(items)
+}
+
+// isZeroFlag reports whether the default of the flag is the zero value of its
+// type, as flag.PrintDefaults decides whether to print it.
+func isZeroFlag(f *flag.Flag) (zero bool) {
+	typ := reflect.TypeOf(f.Value)
+	var v reflect.Value
+	if typ.Kind() == reflect.Ptr {
+		v = reflect.New(typ.Elem())
+	} else {
+		v = reflect.Zero(typ)
+	}
+	defer func() {
+		if recover() != nil {
+			zero = f.DefValue == ""
+		}
+	}()
+	return f.DefValue == v.Interface().(flag.Value).String()
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
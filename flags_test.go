@@ -0,0 +1,64 @@
+package railroad
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestFlags(t *testing.T) {
+	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
+	fs.Bool("i", false, "ignore case")
+	fs.Int("m", 0, "stop after `num` matches")
+	fs.String("color", "auto", "when to use color")
+
+	item := Flags(fs, FlagsArgs("pattern", "[file...]"))
+	add("grep", Diagram(item))
+
+	seq := item.(*sequence)
+	if len(seq.items) != 4 || seq.items[0].(*terminal).text != "grep" {
+		t.Fatalf("got %d items", len(seq.items))
+	}
+	flags := seq.items[1].(*choice).items[1].(*oneOrMore).item.(*choice).items
+	color := flags[0].(*sequence).items
+	if color[1].(*nonTerminal).text != "string" || color[2].(*comment).text != `when to use color (default "auto")` {
+		t.Fatalf("got color flag %+v", color)
+	}
+	if len(flags[1].(*sequence).items) != 2 {
+		t.Fatalf("expected the boolean flag to take no value")
+	}
+	if flags[2].(*sequence).items[1].(*nonTerminal).text != "num" {
+		t.Fatalf("expected the m flag to take a num")
+	}
+
+	for _, test := range []struct {
+		define func(fs *flag.FlagSet)
+		want   string
+	}{
+		{func(fs *flag.FlagSet) { fs.Duration("d", 0, "wait") }, "wait"},
+		{func(fs *flag.FlagSet) { fs.Duration("d", time.Second, "wait") }, "wait (default 1s)"},
+		{func(fs *flag.FlagSet) { fs.String("s", "", "name") }, "name"},
+		{func(fs *flag.FlagSet) { fs.Float64("f", 0.5, "rate") }, "rate (default 0.5)"},
+		{func(fs *flag.FlagSet) { fs.Var(&testFlagValue{"x"}, "v", "value") }, "value (default x)"},
+	} {
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		test.define(fs)
+		usage := Flags(fs).(*choice).items[1].(*oneOrMore).item.(*sequence).items[2].(*comment)
+		if usage.text != test.want {
+			t.Fatalf("got usage %q, want %q", usage.text, test.want)
+		}
+	}
+
+	item = Flags(fs, FlagsAnyOrder(true))
+	add("grep any order", Diagram(item))
+	if _, ok := item.(*sequence).items[1].(*choice).items[1].(*multipleChoice); !ok {
+		t.Fatalf("expected a MultipleChoice of flags")
+	}
+
+	add("empty", Diagram(Flags(flag.NewFlagSet("", flag.ContinueOnError))))
+}
+
+type testFlagValue struct{ s string }
+
+func (v *testFlagValue) String() string     { return v.s }
+func (v *testFlagValue) Set(s string) error { v.s = s; return nil }
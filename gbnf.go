@@ -0,0 +1,297 @@
+package railroad
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ReadGBNF reads a GBNF grammar as used by llama.cpp, producing a diagram for
+// every rule in the order they are defined. String literals are drawn as
+// Terminals, character classes and "." as Terminals with the "char-class"
+// class, and {m,n} repetitions with a Comment for the count.
+func ReadGBNF(r io.Reader) ([]Production, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &gbnfParser{src: string(data), line: 1}
+	p.next()
+
+	var prods []Production
+	for p.kind != 0 && p.err == nil {
+		prods = append(prods, p.rule())
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return prods, nil
+}
+
+type gbnfParser struct {
+	src  string
+	pos  int
+	line int
+
+	kind byte // 'i'dent, 's'tring, 'c'lass, 'r'epetition, '=' for ::=, punctuation, or 0 at the end
+	text string
+	at   int // line of the current token
+	err  error
+}
+
+func (p *gbnfParser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("gbnf: line %d: %s", p.at, fmt.Sprintf(format, args...))
+	}
+	p.kind = 0
+}
+
+// next scans the next token.
+func (p *gbnfParser) next() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			p.scan()
+			return
+		}
+	}
+	p.kind, p.text, p.at = 0, "", p.line
+}
+
+func (p *gbnfParser) scan() {
+	start, c := p.pos, p.src[p.pos]
+	p.at = p.line
+
+	// until advances past the closing byte of a literal, class or repetition.
+	until := func(end byte) bool {
+		for p.pos++; p.pos < len(p.src); p.pos++ {
+			switch p.src[p.pos] {
+			case '\\':
+				p.pos++
+			case '\n':
+				return false
+			case end:
+				p.pos++
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case isGBNFName(c):
+		for p.pos < len(p.src) && isGBNFName(p.src[p.pos]) {
+			p.pos++
+		}
+		p.kind = 'i'
+
+	case strings.HasPrefix(p.src[p.pos:], "::="):
+		p.pos += 3
+		p.kind = '='
+
+	case c == '"' || c == '[' || c == '{':
+		end, kind := byte('"'), byte('s')
+		switch c {
+		case '[':
+			end, kind = ']', 'c'
+		case '{':
+			end, kind = '}', 'r'
+		}
+		if !until(end) {
+			p.text = p.src[start:p.pos]
+			p.errorf("unterminated %c", c)
+			return
+		}
+		p.kind = kind
+
+	case strings.IndexByte("|()?*+.", c) >= 0:
+		p.pos++
+		p.kind = c
+
+	default:
+		p.pos++
+		p.text = p.src[start:p.pos]
+		p.errorf("unexpected %q", c)
+		return
+	}
+	p.text = p.src[start:p.pos]
+}
+
+// peekDefinition reports if the current token begins a new rule.
+func (p *gbnfParser) peekDefinition() bool {
+	if p.kind != 'i' {
+		return false
+	}
+	rest := strings.TrimLeft(p.src[p.pos:], " \t\r\n")
+	return strings.HasPrefix(rest, "::=")
+}
+
+func (p *gbnfParser) rule() Production {
+	prod := Production{Name: p.text, Line: p.at}
+	if p.kind != 'i' {
+		p.errorf("expected rule name, found %q", p.text)
+		return prod
+	}
+	p.next()
+	if p.kind != '=' {
+		p.errorf("expected ::=, found %q", p.text)
+		return prod
+	}
+	p.next()
+	prod.Item = p.alternates()
+	if p.kind != 0 && !p.peekDefinition() {
+		p.errorf("unexpected %q", p.text)
+	}
+	return prod
+}
+
+func (p *gbnfParser) alternates() RailItem {
+	alts := []RailItem{p.sequence()}
+	for p.kind == '|' {
+		p.next()
+		alts = append(alts, p.sequence())
+	}
+	return choiceOf(alts)
+}
+
+func (p *gbnfParser) sequence() RailItem {
+	var items []RailItem
+	for {
+		var item RailItem
+		switch p.kind {
+		case 'i':
+			if p.peekDefinition() {
+				return sequenceOf(items)
+			}
+			item = NonTerminal(p.text)
+			p.next()
+
+		case 's':
+			text, ok := unescapeGBNF(p.text[1 : len(p.text)-1])
+			if !ok {
+				p.errorf("invalid escape in %s", p.text)
+				return Skip()
+			}
+			item = Terminal(text)
+			p.next()
+
+		case 'c':
+			item = Terminal(p.text, TerminalClass("char-class"))
+			p.next()
+
+		case '.':
+			item = Terminal("any character", TerminalClass("char-class"))
+			p.next()
+
+		case '(':
+			p.next()
+			item = p.alternates()
+			if p.kind != ')' {
+				p.errorf("expected ), found %q", p.text)
+				return Skip()
+			}
+			p.next()
+
+		default:
+			return sequenceOf(items)
+		}
+
+		for repeating := true; repeating; {
+			switch p.kind {
+			case '?':
+				item = Optional(item)
+			case '*':
+				item = ZeroOrMore(item)
+			case '+':
+				item = OneOrMore(item)
+			case 'r':
+				min, max, ok := parseGBNFCount(p.text)
+				if !ok {
+					p.errorf("invalid repetition %s", p.text)
+					return Skip()
+				}
+				item = repeatItem(item, min, max)
+			default:
+				repeating = false
+				continue
+			}
+			p.next()
+		}
+		items = append(items, item)
+	}
+}
+
+// parseGBNFCount parses a repetition of the form {m}, {m,} or {m,n}.
+func parseGBNFCount(text string) (min, max int, ok bool) {
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	lo, hi := inner, inner
+	if i := strings.IndexByte(inner, ','); i >= 0 {
+		lo, hi = strings.TrimSpace(inner[:i]), strings.TrimSpace(inner[i+1:])
+	}
+	min, err := strconv.Atoi(lo)
+	if err != nil || min < 0 {
+		return 0, 0, false
+	}
+	if hi == "" {
+		return min, -1, true
+	}
+	max, err = strconv.Atoi(hi)
+	if err != nil || max < min {
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// unescapeGBNF replaces the escapes of a string literal with the characters
+// they stand for.
+func unescapeGBNF(text string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' {
+			b.WriteByte(text[i])
+			continue
+		}
+		if i++; i == len(text) {
+			return "", false
+		}
+		switch c := text[i]; c {
+		case 'x', 'u', 'U':
+			n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+			if i+n >= len(text) {
+				return "", false
+			}
+			r, err := strconv.ParseUint(text[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", false
+			}
+			b.WriteRune(rune(r))
+			i += n
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"', '[', ']':
+			b.WriteByte(c)
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+func isGBNFName(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
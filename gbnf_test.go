@@ -0,0 +1,55 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGBNF = `# a tiny JSON subset
+root   ::= object
+object ::= "{" ws ( pair ( "," ws pair )* )? "}" ws
+
+pair   ::=
+  string ":" ws value
+value  ::= object | string | number | ("true" | "false" | "null") ws
+string ::= "\"" ( [^"\\] | "\\" . )* "\"" ws
+number ::= "-"? [0-9]{1,16} ("." [0-9]+)? ws
+ws     ::= [ \t\n]{0,20}
+`
+
+func TestGBNF(t *testing.T) {
+	prods, err := ReadGBNF(strings.NewReader(testGBNF))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, prod := range prods {
+		names = append(names, prod.Name)
+		add(prod.Name, Diagram(prod.Item))
+	}
+	if got := strings.Join(names, " "); got != "root object pair value string number ws" {
+		t.Fatalf("got rules %q", got)
+	}
+	if prods[2].Line != 5 {
+		t.Fatalf("got line %d for pair", prods[2].Line)
+	}
+	if rep := prods[6].Item.(*choice).items[1].(*oneOrMore); rep.rep.(*comment).text != "at most 20 times" {
+		t.Fatalf("got repetition %q", rep.rep.(*comment).text)
+	}
+
+	prods, err = ReadGBNF(strings.NewReader(`root ::= "a\nb" "\"" "\\" "\xe9\u00e9"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Sequence(Terminal("a\nb"), Terminal(`"`), Terminal(`\`), Terminal("éé"))
+	if !Equal(prods[0].Item, want) {
+		t.Fatalf("got\n%v\nwant\n%v", prods[0].Item, want)
+	}
+
+	for _, bad := range []string{`root ::= "a`, `root ::= "\q"`, `root ::= "\x4"`, `root ::= (a`, `root = a`, `root ::= a{2,1}`, `root ::= a )`} {
+		if _, err := ReadGBNF(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
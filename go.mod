@@ -1 +1,3 @@
 module github.com/zeebo/railroad
+
+go 1.16
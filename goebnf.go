@@ -0,0 +1,156 @@
+package railroad
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/scanner"
+)
+
+// ReadGoEBNF reads productions written in the EBNF dialect of the Go
+// specification and golang.org/x/exp/ebnf:
+//
+//	Production  = name "=" [ Expression ] "." .
+//	Expression  = Alternative { "|" Alternative } .
+//	Alternative = Term { Term } .
+//	Term        = name | token [ "…" token ] | Group | Option | Repetition .
+//	Group       = "(" Expression ")" .
+//	Option      = "[" Expression "]" .
+//	Repetition  = "{" Expression "}" .
+//
+// Tokens are drawn as Terminals, a range of tokens as a single Terminal, and
+// names as NonTerminals.
+func ReadGoEBNF(r io.Reader) ([]Production, error) {
+	p := &goEBNFParser{}
+	p.s.Init(r)
+	p.s.Mode = scanner.ScanIdents | scanner.ScanStrings | scanner.ScanRawStrings |
+		scanner.ScanComments | scanner.SkipComments
+	p.s.Error = func(s *scanner.Scanner, msg string) {
+		if p.err == nil {
+			p.err = fmt.Errorf("ebnf: %s: %s", s.Position, msg)
+		}
+	}
+	p.next()
+
+	var prods []Production
+	for p.tok != scanner.EOF && p.err == nil {
+		prods = append(prods, p.production())
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return prods, nil
+}
+
+type goEBNFParser struct {
+	s   scanner.Scanner
+	pos scanner.Position
+	tok rune
+	lit string
+	err error
+}
+
+func (p *goEBNFParser) next() {
+	p.tok = p.s.Scan()
+	p.pos = p.s.Position
+	p.lit = p.s.TokenText()
+}
+
+func (p *goEBNFParser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("ebnf: %s: %s", p.pos, fmt.Sprintf(format, args...))
+	}
+	p.tok = scanner.EOF
+}
+
+func (p *goEBNFParser) expect(tok rune) {
+	if p.tok != tok {
+		p.errorf("expected %s, found %q", scanner.TokenString(tok), p.lit)
+		return
+	}
+	p.next()
+}
+
+func (p *goEBNFParser) token() string {
+	text, err := strconv.Unquote(p.lit)
+	if err != nil {
+		p.errorf("invalid token %s", p.lit)
+	}
+	p.next()
+	return text
+}
+
+func (p *goEBNFParser) production() Production {
+	prod := Production{Name: p.lit, Line: p.pos.Line}
+	p.expect(scanner.Ident)
+	p.expect('=')
+	if p.tok == '.' {
+		prod.Item = Skip()
+	} else {
+		prod.Item = p.expression()
+	}
+	p.expect('.')
+	return prod
+}
+
+func (p *goEBNFParser) expression() RailItem {
+	alts := []RailItem{p.alternative()}
+	for p.tok == '|' {
+		p.next()
+		alts = append(alts, p.alternative())
+	}
+	return choiceOf(alts)
+}
+
+func (p *goEBNFParser) alternative() RailItem {
+	var terms []RailItem
+	for {
+		switch p.tok {
+		case scanner.Ident, scanner.String, scanner.RawString, '(', '[', '{':
+			terms = append(terms, p.term())
+		default:
+			return sequenceOf(terms)
+		}
+	}
+}
+
+func (p *goEBNFParser) term() RailItem {
+	switch p.tok {
+	case scanner.Ident:
+		name := p.lit
+		p.next()
+		return NonTerminal(name)
+
+	case scanner.String, scanner.RawString:
+		text := p.token()
+		if p.tok != '…' {
+			return Terminal(text)
+		}
+		p.next()
+		if p.tok != scanner.String && p.tok != scanner.RawString {
+			p.errorf("expected token after …, found %q", p.lit)
+			return Skip()
+		}
+		return Terminal(text + " … " + p.token())
+
+	case '(':
+		p.next()
+		item := p.expression()
+		p.expect(')')
+		return item
+
+	case '[':
+		p.next()
+		item := p.expression()
+		p.expect(']')
+		return Optional(item)
+
+	case '{':
+		p.next()
+		item := p.expression()
+		p.expect('}')
+		return ZeroOrMore(item)
+	}
+	p.errorf("unexpected %q", p.lit)
+	return Skip()
+}
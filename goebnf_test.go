@@ -0,0 +1,50 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGoEBNF = `
+Production  = production_name "=" [ Expression ] "." .
+Expression  = Alternative { "|" Alternative } .
+Alternative = Term { Term } .
+Term        = production_name | token [ "…" token ] | Group | Option | Repetition .
+Group       = "(" Expression ")" .
+Option      = "[" Expression "]" .
+Repetition  = "{" Expression "}" .
+
+// lexical productions
+letter = "a" … "z" | "A" … "Z" | "_" .
+empty  = .
+`
+
+func TestGoEBNF(t *testing.T) {
+	prods, err := ReadGoEBNF(strings.NewReader(testGoEBNF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 9 {
+		t.Fatalf("got %d productions", len(prods))
+	}
+	for _, prod := range prods {
+		add(prod.Name, Diagram(prod.Item))
+	}
+
+	letter := prods[7]
+	if letter.Name != "letter" || letter.Line != 11 {
+		t.Fatalf("got %q on line %d", letter.Name, letter.Line)
+	}
+	if text := letter.Item.(*choice).items[0].(*terminal).text; text != "a … z" {
+		t.Fatalf("got range %q", text)
+	}
+	if _, ok := prods[8].Item.(*skip); !ok {
+		t.Fatalf("expected empty production to be a Skip, got %T", prods[8].Item)
+	}
+
+	for _, bad := range []string{`a = "b"`, `a = ( "b" .`, `a = "a" … .`, `= "b" .`} {
+		if _, err := ReadGoEBNF(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package railroad
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GoSource returns gofmt-formatted Go source for an expression that builds
+// the item with the constructors of this package, qualified as railroad.
+// Optional, ZeroOrMore and their options are recognized from the Choice they
+// are built from, so the expression builds an identical tree.
+func GoSource(item RailItem) (string, error) {
+	expr, err := goSourcePrinter.expr(item)
+	if err != nil {
+		return "", err
+	}
+	const prefix = "package p\n\nvar _ = "
+	src, err := format.Source([]byte(prefix + expr))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(src), prefix)), nil
+}
+
+// GoFile returns a gofmt-formatted Go source file in the package declaring a
+// function for every production that returns its diagram. The functions are
+// named after the productions in camel case, like commentText for "comment
+// text".
+func GoFile(pkg string, prods ...Production) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n\"io\"\n\n\"github.com/zeebo/railroad\"\n)\n", pkg)
+
+	seen := make(map[string]bool)
+	for _, prod := range prods {
+		name := goIdent(prod.Name)
+		for i := 2; seen[name]; i++ {
+			name = fmt.Sprintf("%s%d", goIdent(prod.Name), i)
+		}
+		seen[name] = true
+
+		expr, err := goSourcePrinter.expr(prod.Item)
+		if err != nil {
+			return nil, fmt.Errorf("go: %s: %v", prod.Name, err)
+		}
+		fmt.Fprintf(&buf, "\n// %s returns the diagram of %s.\nfunc %s() io.WriterTo {\nreturn railroad.Diagram(%s)\n}\n",
+			name, strconv.Quote(prod.Name), name, expr)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// goIdent returns the name in camel case, starting with a lower case letter.
+func goIdent(name string) string {
+	fields := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, field := range fields {
+		r := []rune(field)
+		if i == 0 {
+			r[0] = unicode.ToLower(r[0])
+		} else {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	ident := b.String()
+	switch {
+	case ident == "":
+		ident = "rule"
+	case !unicode.IsLetter([]rune(ident)[0]) || token.IsKeyword(ident):
+		ident = "rule" + strings.ToUpper(ident[:1]) + ident[1:]
+	}
+	return ident
+}
+
+// goPrinter prints the expressions that build items.
+type goPrinter struct {
+	qual   string // qualifies the names of the package
+	width  int    // longest call written on one line
+	indent string // prefix of the arguments of longer calls
+}
+
+// goSourcePrinter prints the expressions of GoSource and GoFile, which are
+// indented by go/format.
+var goSourcePrinter = goPrinter{qual: "railroad.", width: 80}
+
+// expr returns the expression that builds the item, on several lines if it
+// is long.
+func (p goPrinter) expr(item RailItem) (string, error) {
+	switch item := item.(type) {
+	case *skip:
+		return p.qual + "Skip()", nil
+
+	case *terminal:
+		args := []string{strconv.Quote(item.text)}
+		if item.class != "" {
+			args = append(args, p.qual+"TerminalClass("+strconv.Quote(item.class)+")")
+		}
+		return p.call("Terminal", args), nil
+
+	case *nonTerminal:
+		args := []string{strconv.Quote(item.text)}
+		if item.href != "" {
+			args = append(args, p.qual+"NonTerminalHref("+strconv.Quote(item.href)+")")
+		}
+		return p.call("NonTerminal", args), nil
+
+	case *comment:
+		return p.call("Comment", []string{strconv.Quote(item.text)}), nil
+
+	case *sequence:
+		return p.items("Sequence", nil, item.items)
+
+	case *stack:
+		return p.items("Stack", nil, item.items)
+
+	case *optionalSequence:
+		return p.items("OptionalSequence", nil, item.items)
+
+	case *choice:
+		if len(item.items) == 2 && item.def <= 1 {
+			if _, ok := item.items[0].(*skip); ok {
+				return p.optional(item.items[1], item.def == 0)
+			}
+		}
+		return p.items("Choice", []string{strconv.Itoa(item.def)}, item.items)
+
+	case *multipleChoice:
+		type_ := p.qual + "MultipleChoiceAny"
+		if MultipleChoiceType(item.type_) == MultipleChoiceAll {
+			type_ = p.qual + "MultipleChoiceAll"
+		}
+		return p.items("MultipleChoice", []string{strconv.Itoa(item.def), type_}, item.items)
+
+	case *oneOrMore:
+		return p.repeat("OneOrMore", item, false)
+
+	case *group:
+		inner, err := p.expr(item.item)
+		if err != nil {
+			return "", err
+		}
+		label := ""
+		if item.label != nil {
+			label = item.label.(*comment).text
+		}
+		return p.call("Group", []string{inner, strconv.Quote(label)}), nil
+	}
+	return "", fmt.Errorf("unsupported item %T", item)
+}
+
+// optional returns the Optional, or ZeroOrMore if the item is a OneOrMore,
+// built from a Choice of Skip and the item.
+func (p goPrinter) optional(item RailItem, skipFirst bool) (string, error) {
+	if rep, ok := item.(*oneOrMore); ok {
+		return p.repeat("ZeroOrMore", rep, skipFirst)
+	}
+	inner, err := p.expr(item)
+	if err != nil {
+		return "", err
+	}
+	args := []string{inner}
+	if skipFirst {
+		args = append(args, p.qual+"OptionalSkip(true)")
+	}
+	return p.call("Optional", args), nil
+}
+
+// repeat returns the OneOrMore or ZeroOrMore repeating the item of the
+// OneOrMore.
+func (p goPrinter) repeat(name string, item *oneOrMore, skipFirst bool) (string, error) {
+	inner, err := p.expr(item.item)
+	if err != nil {
+		return "", err
+	}
+	args := []string{inner}
+	if _, ok := item.rep.(*skip); !ok {
+		rep, err := p.expr(item.rep)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, p.qual+name+"Repeat("+rep+")")
+	}
+	if skipFirst {
+		args = append(args, p.qual+"ZeroOrMoreSkip(true)")
+	}
+	return p.call(name, args), nil
+}
+
+func (p goPrinter) items(name string, args []string, items []RailItem) (string, error) {
+	for _, item := range items {
+		arg, err := p.expr(item)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+	return p.call(name, args), nil
+}
+
+// call returns a call of the constructor with the arguments, with one
+// argument per line if it doesn't fit on one.
+func (p goPrinter) call(name string, args []string) string {
+	line := p.qual + name + "(" + strings.Join(args, ", ") + ")"
+	if len(line) <= p.width && !strings.Contains(line, "\n") {
+		return line
+	}
+	var b strings.Builder
+	b.WriteString(p.qual + name + "(\n")
+	for _, arg := range args {
+		b.WriteString(p.indent + strings.Replace(arg, "\n", "\n"+p.indent, -1) + ",\n")
+	}
+	b.WriteString(")")
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package railroad
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGoSource(t *testing.T) {
+	src, err := GoSource(Sequence(
+		Terminal("{", TerminalClass("brace")),
+		Optional(NonTerminal("x", NonTerminalHref("#x")), OptionalSkip(true)),
+		ZeroOrMore(NonTerminal("member"), ZeroOrMoreRepeat(Terminal(",")), ZeroOrMoreSkip(true)),
+		Choice(2, Skip(), Terminal("a"), Terminal("b")),
+		Group(OneOrMore(Comment("c")), "label"),
+		Terminal("}"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `railroad.Sequence(
+	railroad.Terminal("{", railroad.TerminalClass("brace")),
+	railroad.Optional(
+		railroad.NonTerminal("x", railroad.NonTerminalHref("#x")),
+		railroad.OptionalSkip(true),
+	),
+	railroad.ZeroOrMore(
+		railroad.NonTerminal("member"),
+		railroad.ZeroOrMoreRepeat(railroad.Terminal(",")),
+		railroad.ZeroOrMoreSkip(true),
+	),
+	railroad.Choice(
+		2,
+		railroad.Skip(),
+		railroad.Terminal("a"),
+		railroad.Terminal("b"),
+	),
+	railroad.Group(railroad.OneOrMore(railroad.Comment("c")), "label"),
+	railroad.Terminal("}"),
+)`
+	if src != want {
+		t.Fatalf("got\n%s\nwant\n%s", src, want)
+	}
+
+	file, err := GoFile("grammar",
+		Production{Name: "comment text", Item: Optional(Terminal("a"))},
+		Production{Name: "comment-text", Item: MultipleChoice(0, MultipleChoiceAll, Terminal("a"), Stack(Terminal("b")))},
+		Production{Name: "type", Item: OptionalSequence(Terminal("a"), Terminal("b"))},
+		Production{Name: "1st", Item: OneOrMore(Terminal("a"), OneOrMoreRepeat(Terminal(",")))},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "grammar.go", file, 0); err != nil {
+		t.Fatalf("%v\n%s", err, file)
+	}
+	for _, want := range []string{"func commentText()", "func commentText2()", "func ruleType()", "func rule1st()"} {
+		if !strings.Contains(string(file), want) {
+			t.Fatalf("expected %q in\n%s", want, file)
+		}
+	}
+
+	if _, err := GoSource(Diagram(Skip()).(RailItem)); err == nil {
+		t.Fatal("expected an error for a diagram")
+	}
+}
@@ -0,0 +1,164 @@
+package railroad
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Grammar is a set of productions whose NonTerminals refer to each other by
+// name. The first production is the start rule.
+type Grammar struct {
+	Productions []Production
+
+	diagrams map[RailItem]io.WriterTo // by the item of their production
+}
+
+// NewGrammar returns a grammar of the productions.
+func NewGrammar(prods ...Production) *Grammar {
+	return &Grammar{Productions: prods}
+}
+
+// Rule returns the first production with the name.
+func (g *Grammar) Rule(name string) (Production, bool) {
+	for _, prod := range g.Productions {
+		if prod.Name == name {
+			return prod, true
+		}
+	}
+	return Production{}, false
+}
+
+// rules returns the index of the first production with each name.
+func (g *Grammar) rules() map[string]int {
+	rules := make(map[string]int, len(g.Productions))
+	for i, prod := range g.Productions {
+		if _, ok := rules[prod.Name]; !ok {
+			rules[prod.Name] = i
+		}
+	}
+	return rules
+}
+
+// References returns the names of the NonTerminals in the named rule, in the
+// order they first appear.
+func (g *Grammar) References(name string) []string {
+	prod, ok := g.Rule(name)
+	if !ok {
+		return nil
+	}
+	return references(prod.Item)
+}
+
+// Undefined returns the names of the NonTerminals that have no rule, in the
+// order they first appear.
+func (g *Grammar) Undefined() []string {
+	var names []string
+	rules, seen := g.rules(), make(map[string]bool)
+	for _, prod := range g.Productions {
+		for _, name := range references(prod.Item) {
+			if _, ok := rules[name]; !ok && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Unused returns the names of the rules, other than the start rule, that no
+// other rule refers to.
+func (g *Grammar) Unused() []string {
+	used := make(map[string]bool)
+	for _, prod := range g.Productions {
+		for _, name := range references(prod.Item) {
+			if name != prod.Name {
+				used[name] = true
+			}
+		}
+	}
+
+	var names []string
+	for i, prod := range g.Productions {
+		if i > 0 && !used[prod.Name] {
+			names = append(names, prod.Name)
+		}
+	}
+	return names
+}
+
+// Anchor returns the id of the heading WriteTo writes for the named rule.
+func (g *Grammar) Anchor(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// WriteTo writes a heading and a diagram for every rule as HTML. Each
+// NonTerminal that refers to a rule and has no link of its own is linked to
+// the rule's heading. Like the items in it, the diagram of a rule is laid out
+// the first time it is written, so rules added afterwards aren't linked from
+// it.
+func (g *Grammar) WriteTo(w io.Writer) (n int64, err error) {
+	if g.diagrams == nil {
+		g.diagrams = make(map[RailItem]io.WriterTo)
+	}
+	rules := g.rules()
+	for _, prod := range g.Productions {
+		if _, ok := g.diagrams[prod.Item]; !ok {
+			g.link(prod.Item, rules)
+			g.diagrams[prod.Item] = Diagram(prod.Item)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, prod := range g.Productions {
+		id := ""
+		if anchor := g.Anchor(prod.Name); !seen[anchor] {
+			seen[anchor] = true
+			id = fmt.Sprintf(` id="%s"`, html.EscapeString(anchor))
+		}
+		ni, err := fmt.Fprintf(w, "<h2%s>%s</h2>\n", id, html.EscapeString(prod.Name))
+		n += int64(ni)
+		if err != nil {
+			return n, err
+		}
+		nd, err := g.diagrams[prod.Item].WriteTo(w)
+		n += nd
+		if err != nil {
+			return n, err
+		}
+		ni, err = fmt.Fprintln(w)
+		n += int64(ni)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// link links the NonTerminals in the item to the headings of their rules.
+func (g *Grammar) link(item RailItem, rules map[string]int) {
+	Walk(item, func(item RailItem) bool {
+		if nt, ok := item.(*nonTerminal); ok && nt.href == "" {
+			if _, ok := rules[nt.text]; ok {
+				nt.href = "#" + g.Anchor(nt.text)
+			}
+		}
+		return true
+	})
+}
+
+// references returns the names of the NonTerminals in the item, in the order
+// they first appear.
+func references(item RailItem) []string {
+	var names []string
+	seen := make(map[string]bool)
+	Walk(item, func(item RailItem) bool {
+		if nt, ok := item.(*nonTerminal); ok && !seen[nt.text] {
+			seen[nt.text] = true
+			names = append(names, nt.text)
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,66 @@
+package railroad
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrammar(t *testing.T) {
+	g := NewGrammar(
+		Production{Name: "list", Item: Sequence(
+			Terminal("("),
+			ZeroOrMore(NonTerminal("item"), ZeroOrMoreRepeat(Terminal(","))),
+			Terminal(")"),
+		)},
+		Production{Name: "item", Item: Choice(0, NonTerminal("list"), NonTerminal("atom"), NonTerminal("item"))},
+		Production{Name: "comment text", Item: Group(NonTerminal("text", NonTerminalHref("/text")), "text")},
+		Production{Name: "unused", Item: NonTerminal("unused")},
+	)
+
+	if got := g.References("item"); !reflect.DeepEqual(got, []string{"list", "atom", "item"}) {
+		t.Fatalf("got references %q", got)
+	}
+	if got := g.Undefined(); !reflect.DeepEqual(got, []string{"atom", "text"}) {
+		t.Fatalf("got undefined %q", got)
+	}
+	if got := g.Unused(); !reflect.DeepEqual(got, []string{"comment text", "unused"}) {
+		t.Fatalf("got unused %q", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<h2 id="comment-text">comment text</h2>`,
+		`xlink:href="#item"`,
+		`xlink:href="/text"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output", want)
+		}
+	}
+	if strings.Contains(out, `xlink:href="#atom"`) {
+		t.Fatal("expected no link to an undefined rule")
+	}
+
+	var again bytes.Buffer
+	if _, err := g.WriteTo(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != out {
+		t.Fatal("expected the grammar to write the same output twice")
+	}
+
+	g.Productions = append(g.Productions, Production{Name: "atom", Item: Terminal("a")})
+	again.Reset()
+	if _, err := g.WriteTo(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(again.String(), out) || !strings.Contains(again.String(), `<h2 id="atom">atom</h2>`) {
+		t.Fatal("expected an added rule to be written after the others")
+	}
+}
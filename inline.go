@@ -0,0 +1,72 @@
+package railroad
+
+type InlineOption struct {
+	depth *int
+	size  *int
+}
+
+// InlineDepth sets how many levels of references are expanded. It is 1 by
+// default, which expands the rules each rule refers to but not the rules
+// those refer to.
+func InlineDepth(depth int) InlineOption { return InlineOption{depth: &depth} }
+
+// InlineSize limits the expansion to rules of at most size items. It is 0 by
+// default, which expands rules of any size.
+func InlineSize(size int) InlineOption { return InlineOption{size: &size} }
+
+// Inline returns a copy of the grammar with the NonTerminals that refer to its
+// rules replaced by the rules themselves, drawn in a box labeled with the
+// rule's name. A reference to a rule that is already being expanded is kept as
+// a NonTerminal, so recursive rules expand only once.
+func (g *Grammar) Inline(options ...InlineOption) *Grammar {
+	depth, size := 1, 0
+	for _, opt := range options {
+		if opt.depth != nil {
+			depth = *opt.depth
+		}
+		if opt.size != nil {
+			size = *opt.size
+		}
+	}
+
+	prods := make([]Production, len(g.Productions))
+	for i, prod := range g.Productions {
+		prod.Item = g.inline(prod.Item, []string{prod.Name}, depth, size)
+		prods[i] = prod
+	}
+	return NewGrammar(prods...)
+}
+
+// inline returns a copy of the item with the rules it refers to expanded to
+// the depth, unless they are in expanding.
+func (g *Grammar) inline(item RailItem, expanding []string, depth, size int) RailItem {
+	return Rewrite(item, func(item RailItem) RailItem {
+		nt, ok := item.(*nonTerminal)
+		if !ok || depth <= 0 {
+			return item
+		}
+		for _, name := range expanding {
+			if name == nt.text {
+				return item
+			}
+		}
+		rule, ok := g.Rule(nt.text)
+		if !ok || (size > 0 && itemSize(rule.Item) > size) {
+			return item
+		}
+		expanding := append(expanding[:len(expanding):len(expanding)], nt.text)
+		return Group(g.inline(rule.Item, expanding, depth-1, size), nt.text)
+	})
+}
+
+// itemSize returns the number of items in the item, not counting Skips.
+func itemSize(item RailItem) int {
+	n := 0
+	Walk(item, func(item RailItem) bool {
+		if KindOf(item) != KindSkip {
+			n++
+		}
+		return true
+	})
+	return n
+}
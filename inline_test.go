@@ -0,0 +1,57 @@
+package railroad
+
+import "testing"
+
+func TestInline(t *testing.T) {
+	g := NewGrammar(
+		Production{Name: "list", Item: Sequence(
+			Terminal("("),
+			ZeroOrMore(NonTerminal("value"), ZeroOrMoreRepeat(NonTerminal("comma"))),
+			Terminal(")"),
+		)},
+		Production{Name: "value", Item: Choice(0, NonTerminal("list"), NonTerminal("number"), NonTerminal("value"))},
+		Production{Name: "number", Item: OneOrMore(NonTerminal("digit"))},
+		Production{Name: "digit", Item: Terminal("0-9", TerminalClass("char-class"))},
+		Production{Name: "comma", Item: Terminal(",")},
+	)
+
+	out := g.Inline()
+	want := Sequence(
+		Terminal("("),
+		ZeroOrMore(
+			Group(Choice(0, NonTerminal("list"), NonTerminal("number"), NonTerminal("value")), "value"),
+			ZeroOrMoreRepeat(Group(Terminal(","), "comma"))),
+		Terminal(")"),
+	)
+	if !Equal(out.Productions[0].Item, want) {
+		t.Fatalf("got\n%v\nwant\n%v", out.Productions[0].Item, want)
+	}
+
+	out = g.Inline(InlineDepth(3))
+	want = Choice(0,
+		Group(Sequence(
+			Terminal("("),
+			ZeroOrMore(NonTerminal("value"), ZeroOrMoreRepeat(Group(Terminal(","), "comma"))),
+			Terminal(")"),
+		), "list"),
+		Group(OneOrMore(Group(Terminal("0-9", TerminalClass("char-class")), "digit")), "number"),
+		NonTerminal("value"),
+	)
+	if !Equal(out.Productions[1].Item, want) {
+		t.Fatalf("got\n%v\nwant\n%v", out.Productions[1].Item, want)
+	}
+
+	out = g.Inline(InlineSize(1))
+	want = Choice(0, NonTerminal("list"), NonTerminal("number"), NonTerminal("value"))
+	if !Equal(out.Productions[1].Item, want) {
+		t.Fatalf("got\n%v\nwant\n%v", out.Productions[1].Item, want)
+	}
+	if KindOf(out.Productions[2].Item.(*oneOrMore).item) != KindGroup {
+		t.Fatal("expected a small rule to be inlined")
+	}
+
+	if !Equal(g.Inline(InlineDepth(0)).Productions[0].Item, g.Productions[0].Item) {
+		t.Fatal("expected no expansion at depth 0")
+	}
+	add("inline", Diagram(g.Inline(InlineDepth(2)).Productions[0].Item))
+}
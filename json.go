@@ -0,0 +1,267 @@
+package railroad
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonItem is the JSON form of an item. Type is the name of the constructor
+// that builds it, and the rest are its arguments and options:
+//
+//	{"type": "Terminal", "text": "+", "class": "op"}
+//	{"type": "NonTerminal", "text": "expr", "href": "#expr"}
+//	{"type": "Comment", "text": "1-6 times"}
+//	{"type": "Skip"}
+//	{"type": "Sequence", "items": [...]}, and likewise Stack and OptionalSequence
+//	{"type": "Choice", "default": 0, "items": [...]}
+//	{"type": "MultipleChoice", "default": 0, "choiceType": "all", "items": [...]}
+//	{"type": "Optional", "item": {...}, "skip": true}
+//	{"type": "OneOrMore", "item": {...}, "repeat": {...}}
+//	{"type": "ZeroOrMore", "item": {...}, "repeat": {...}, "skip": true}
+//	{"type": "Group", "item": {...}, "label": "name"}
+type jsonItem struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	Class      string      `json:"class,omitempty"`
+	Href       string      `json:"href,omitempty"`
+	Label      string      `json:"label,omitempty"`
+	Default    *int        `json:"default,omitempty"`
+	ChoiceType string      `json:"choiceType,omitempty"`
+	Skip       bool        `json:"skip,omitempty"`
+	Item       *jsonItem   `json:"item,omitempty"`
+	Repeat     *jsonItem   `json:"repeat,omitempty"`
+	Items      []*jsonItem `json:"items,omitempty"`
+}
+
+// MarshalItem returns the JSON form of the item. Every node is an object
+// whose "type" is the name of the constructor that builds it, with its text,
+// default, MultipleChoice type, repeat, skip, class, link and label as other
+// fields. Optional and ZeroOrMore are recognized from the Choice they are
+// built from.
+func MarshalItem(item RailItem) ([]byte, error) {
+	node, err := toJSONItem(item)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+// UnmarshalItem returns the item whose JSON form is data.
+func UnmarshalItem(data []byte) (RailItem, error) {
+	var node jsonItem
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return node.item()
+}
+
+// MarshalJSON returns the production as an object with its name, line and
+// the JSON form of its item.
+func (p Production) MarshalJSON() ([]byte, error) {
+	item, err := toJSONItem(p.Item)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", p.Name, err)
+	}
+	return json.Marshal(struct {
+		Name string    `json:"name"`
+		Item *jsonItem `json:"item"`
+		Line int       `json:"line,omitempty"`
+	}{p.Name, item, p.Line})
+}
+
+// UnmarshalJSON reads a production written by MarshalJSON.
+func (p *Production) UnmarshalJSON(data []byte) error {
+	var prod struct {
+		Name string    `json:"name"`
+		Item *jsonItem `json:"item"`
+		Line int       `json:"line"`
+	}
+	if err := json.Unmarshal(data, &prod); err != nil {
+		return err
+	}
+	if prod.Item == nil {
+		return fmt.Errorf("json: %s: missing item", prod.Name)
+	}
+	item, err := prod.Item.item()
+	if err != nil {
+		return fmt.Errorf("%s: %v", prod.Name, err)
+	}
+	*p = Production{Name: prod.Name, Item: item, Line: prod.Line}
+	return nil
+}
+
+func toJSONItem(item RailItem) (*jsonItem, error) {
+	switch item := item.(type) {
+	case *skip:
+		return &jsonItem{Type: "Skip"}, nil
+	case *terminal:
+		return &jsonItem{Type: "Terminal", Text: item.text, Class: item.class}, nil
+	case *nonTerminal:
+		return &jsonItem{Type: "NonTerminal", Text: item.text, Href: item.href}, nil
+	case *comment:
+		return &jsonItem{Type: "Comment", Text: item.text}, nil
+	case *sequence:
+		return toJSONItems(&jsonItem{Type: "Sequence"}, item.items)
+	case *stack:
+		return toJSONItems(&jsonItem{Type: "Stack"}, item.items)
+	case *optionalSequence:
+		return toJSONItems(&jsonItem{Type: "OptionalSequence"}, item.items)
+
+	case *choice:
+		if len(item.items) == 2 && item.def <= 1 {
+			if _, ok := item.items[0].(*skip); ok {
+				node := &jsonItem{Type: "Optional", Skip: item.def == 0}
+				inner := item.items[1]
+				if rep, ok := inner.(*oneOrMore); ok {
+					node.Type = "ZeroOrMore"
+					return toJSONRepeat(node, rep)
+				}
+				var err error
+				node.Item, err = toJSONItem(inner)
+				return node, err
+			}
+		}
+		def := item.def
+		return toJSONItems(&jsonItem{Type: "Choice", Default: &def}, item.items)
+
+	case *multipleChoice:
+		def := item.def
+		return toJSONItems(&jsonItem{Type: "MultipleChoice", Default: &def, ChoiceType: item.type_}, item.items)
+
+	case *oneOrMore:
+		return toJSONRepeat(&jsonItem{Type: "OneOrMore"}, item)
+
+	case *group:
+		node := &jsonItem{Type: "Group"}
+		if item.label != nil {
+			node.Label = item.label.(*comment).text
+		}
+		var err error
+		node.Item, err = toJSONItem(item.item)
+		return node, err
+	}
+	return nil, fmt.Errorf("json: unsupported item %T", item)
+}
+
+func toJSONItems(node *jsonItem, items []RailItem) (*jsonItem, error) {
+	for _, item := range items {
+		child, err := toJSONItem(item)
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, child)
+	}
+	return node, nil
+}
+
+// toJSONRepeat fills in the item and repeat of a OneOrMore or ZeroOrMore.
+func toJSONRepeat(node *jsonItem, item *oneOrMore) (*jsonItem, error) {
+	var err error
+	if node.Item, err = toJSONItem(item.item); err != nil {
+		return nil, err
+	}
+	if _, ok := item.rep.(*skip); !ok {
+		if node.Repeat, err = toJSONItem(item.rep); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func (n *jsonItem) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("json: %s: %s", n.Type, fmt.Sprintf(format, args...))
+}
+
+// item returns the item the node describes.
+func (n *jsonItem) item() (RailItem, error) {
+	var item, repeat RailItem
+	if n.Item != nil {
+		var err error
+		if item, err = n.Item.item(); err != nil {
+			return nil, err
+		}
+	}
+	if n.Repeat != nil {
+		var err error
+		if repeat, err = n.Repeat.item(); err != nil {
+			return nil, err
+		}
+	}
+	items := make([]RailItem, 0, len(n.Items))
+	for _, child := range n.Items {
+		if child == nil {
+			return nil, n.errorf("null item")
+		}
+		it, err := child.item()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+
+	switch n.Type {
+	case "Optional", "OneOrMore", "ZeroOrMore", "Group":
+		if item == nil {
+			return nil, n.errorf("missing item")
+		}
+	case "Sequence", "Stack", "OptionalSequence", "Choice", "MultipleChoice":
+		if len(items) == 0 {
+			return nil, n.errorf("missing items")
+		}
+	}
+	def := 0
+	if n.Default != nil {
+		def = *n.Default
+	}
+
+	switch n.Type {
+	case "Skip":
+		return Skip(), nil
+	case "Terminal":
+		var options []TerminalOption
+		if n.Class != "" {
+			options = append(options, TerminalClass(n.Class))
+		}
+		return Terminal(n.Text, options...), nil
+	case "NonTerminal":
+		var options []NonTerminalOption
+		if n.Href != "" {
+			options = append(options, NonTerminalHref(n.Href))
+		}
+		return NonTerminal(n.Text, options...), nil
+	case "Comment":
+		return Comment(n.Text), nil
+	case "Sequence":
+		return Sequence(items...), nil
+	case "Stack":
+		return Stack(items...), nil
+	case "OptionalSequence":
+		return OptionalSequence(items...), nil
+
+	case "Choice":
+		if def < 0 || def >= len(items) {
+			return nil, n.errorf("default %d out of range", def)
+		}
+		return Choice(def, items...), nil
+
+	case "MultipleChoice":
+		if def < 0 || def >= len(items) {
+			return nil, n.errorf("default %d out of range", def)
+		}
+		switch type_ := MultipleChoiceType(n.ChoiceType); type_ {
+		case MultipleChoiceAny, MultipleChoiceAll:
+			return MultipleChoice(def, type_, items...), nil
+		}
+		return nil, n.errorf("unknown choice type %q", n.ChoiceType)
+
+	case "Optional":
+		return Optional(item, OptionalSkip(n.Skip)), nil
+	case "OneOrMore":
+		return OneOrMore(item, OneOrMoreRepeat(repeat)), nil
+	case "ZeroOrMore":
+		return ZeroOrMore(item, ZeroOrMoreRepeat(repeat), ZeroOrMoreSkip(n.Skip)), nil
+	case "Group":
+		return Group(item, n.Label), nil
+	}
+	return nil, fmt.Errorf("json: unknown type %q", n.Type)
+}
@@ -0,0 +1,71 @@
+package railroad
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	item := Sequence(
+		Terminal("{", TerminalClass("brace")),
+		Optional(NonTerminal("x", NonTerminalHref("#x")), OptionalSkip(true)),
+		ZeroOrMore(NonTerminal("member"), ZeroOrMoreRepeat(Terminal(","))),
+		Choice(0, Terminal("a"), Skip()),
+		MultipleChoice(1, MultipleChoiceAll, Terminal("b"), Stack(Terminal("c"), Comment("d"))),
+		OptionalSequence(Terminal("e"), Terminal("f")),
+		Group(OneOrMore(Terminal("g"), OneOrMoreRepeat(Comment("2+ times"))), "label"),
+	)
+	data, err := MarshalItem(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := UnmarshalItem(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := GoSource(item)
+	if got, _ := GoSource(back); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	add("json", Diagram(back))
+
+	data, err = MarshalItem(Optional(Terminal("a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"Optional","item":{"type":"Terminal","text":"a"}}` {
+		t.Fatalf("got %s", data)
+	}
+
+	data, err = json.Marshal([]Production{{Name: "a", Item: Choice(0, Terminal("x"), Terminal("y")), Line: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[{"name":"a","item":{"type":"Choice","default":0,"items":[{"type":"Terminal","text":"x"},{"type":"Terminal","text":"y"}]},"line":3}]` {
+		t.Fatalf("got %s", data)
+	}
+	var prods []Production
+	if err := json.Unmarshal(data, &prods); err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 1 || prods[0].Name != "a" || prods[0].Line != 3 || len(prods[0].Item.(*choice).items) != 2 {
+		t.Fatalf("got %+v", prods)
+	}
+
+	for _, bad := range []string{
+		`{"type":"Wat"}`,
+		`{"type":"Sequence"}`,
+		`{"type":"Optional"}`,
+		`{"type":"Choice","default":2,"items":[{"type":"Skip"}]}`,
+		`{"type":"MultipleChoice","choiceType":"some","items":[{"type":"Skip"}]}`,
+		`{"type":"Sequence","items":[null]}`,
+		`[]`,
+	} {
+		if _, err := UnmarshalItem([]byte(bad)); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+	if err := json.Unmarshal([]byte(`[{"name":"a"}]`), &prods); err == nil {
+		t.Fatal("expected an error for a missing item")
+	}
+}
@@ -0,0 +1,294 @@
+package railroad
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+type jsonSchema struct {
+	Ref         string            `json:"$ref"`
+	Title       string            `json:"title"`
+	Type        json.RawMessage   `json:"type"`
+	Properties  json.RawMessage   `json:"properties"`
+	Required    []string          `json:"required"`
+	Additional  json.RawMessage   `json:"additionalProperties"`
+	Items       json.RawMessage   `json:"items"`
+	PrefixItems []json.RawMessage `json:"prefixItems"`
+	MinItems    int               `json:"minItems"`
+	Enum        []json.RawMessage `json:"enum"`
+	Const       json.RawMessage   `json:"const"`
+	OneOf       []json.RawMessage `json:"oneOf"`
+	AnyOf       []json.RawMessage `json:"anyOf"`
+	Definitions json.RawMessage   `json:"definitions"`
+	Defs        json.RawMessage   `json:"$defs"`
+}
+
+// ReadJSONSchema reads a JSON Schema document, producing a diagram of the
+// shape of the JSON text it describes. The first production is the schema
+// itself, named by its title or "root", followed by one for each of its
+// definitions. Objects are drawn with their properties in order, optional
+// when not required, enums and oneOf or anyOf as Choices, and $refs as
+// NonTerminals linked to "#" followed by the name of the definition.
+func ReadJSONSchema(r io.Reader) ([]Production, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("json schema: %v", err)
+	}
+
+	name := schema.Title
+	if name == "" {
+		name = "root"
+	}
+	item, err := jsonSchemaItem(data)
+	if err != nil {
+		return nil, fmt.Errorf("json schema: %v", err)
+	}
+	prods := []Production{{Name: name, Item: item}}
+
+	for _, defs := range []json.RawMessage{schema.Definitions, schema.Defs} {
+		if defs == nil {
+			continue
+		}
+		names, values, err := jsonObject(defs)
+		if err != nil {
+			return nil, fmt.Errorf("json schema: definitions: %v", err)
+		}
+		for _, name := range names {
+			item, err := jsonSchemaItem(values[name])
+			if err != nil {
+				return nil, fmt.Errorf("json schema: %s: %v", name, err)
+			}
+			prods = append(prods, Production{Name: name, Item: item})
+		}
+	}
+	return prods, nil
+}
+
+func jsonSchemaItem(data json.RawMessage) (RailItem, error) {
+	switch string(bytes.TrimSpace(data)) {
+	case "true", "{}":
+		return NonTerminal("value"), nil
+	case "false":
+		return Comment("nothing"), nil
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	subs := func(datas []json.RawMessage) ([]RailItem, error) {
+		items := make([]RailItem, 0, len(datas))
+		for _, data := range datas {
+			item, err := jsonSchemaItem(data)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	switch {
+	case schema.Ref != "":
+		for _, prefix := range []string{"#/definitions/", "#/$defs/"} {
+			if strings.HasPrefix(schema.Ref, prefix) {
+				name := schema.Ref[len(prefix):]
+				return NonTerminal(name, NonTerminalHref("#"+name)), nil
+			}
+		}
+		return NonTerminal(schema.Ref, NonTerminalHref(schema.Ref)), nil
+
+	case schema.Const != nil:
+		return Terminal(jsonSchemaValue(schema.Const)), nil
+
+	case schema.Enum != nil:
+		items := make([]RailItem, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			items = append(items, Terminal(jsonSchemaValue(value)))
+		}
+		return choiceOf(items), nil
+
+	case schema.OneOf != nil || schema.AnyOf != nil:
+		items, err := subs(append(schema.OneOf, schema.AnyOf...))
+		if err != nil {
+			return nil, err
+		}
+		return choiceOf(items), nil
+	}
+
+	var types []string
+	if schema.Type != nil {
+		var typ string
+		if err := json.Unmarshal(schema.Type, &typ); err == nil {
+			types = []string{typ}
+		} else if err := json.Unmarshal(schema.Type, &types); err != nil {
+			return nil, fmt.Errorf("invalid type %s", schema.Type)
+		}
+	}
+	switch {
+	case types != nil:
+	case schema.Properties != nil || schema.Additional != nil:
+		types = []string{"object"}
+	case schema.Items != nil || schema.PrefixItems != nil:
+		types = []string{"array"}
+	default:
+		return NonTerminal("value"), nil
+	}
+
+	items := make([]RailItem, 0, len(types))
+	for _, typ := range types {
+		var item RailItem
+		switch typ {
+		case "object":
+			var err error
+			if item, err = jsonSchemaObject(&schema); err != nil {
+				return nil, err
+			}
+		case "array":
+			var err error
+			if item, err = jsonSchemaArray(&schema); err != nil {
+				return nil, err
+			}
+		case "boolean":
+			item = Choice(0, Terminal("true"), Terminal("false"))
+		case "null":
+			item = Terminal("null")
+		case "string", "number", "integer":
+			item = NonTerminal(typ)
+		default:
+			return nil, fmt.Errorf("unknown type %q", typ)
+		}
+		items = append(items, item)
+	}
+	return choiceOf(items), nil
+}
+
+func jsonSchemaObject(schema *jsonSchema) (RailItem, error) {
+	items := []RailItem{Terminal("{")}
+
+	if schema.Properties != nil {
+		names, values, err := jsonObject(schema.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("properties: %v", err)
+		}
+		required := make(map[string]bool)
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+		var props [][]RailItem
+		first := -1 // index of the first required property
+		for i, name := range names {
+			value, err := jsonSchemaItem(values[name])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			props = append(props, []RailItem{Terminal(`"` + name + `"`), Terminal(":"), value})
+			if first < 0 && required[name] {
+				first = i
+			}
+		}
+
+		// commas go after the optional properties before the first required
+		// one and before the properties after it, so that each is only
+		// there when a property on both sides is. Without a required
+		// property, the one that comes first is chosen.
+		switch {
+		case len(props) == 0:
+		case first < 0:
+			var alts []RailItem
+			for i, prop := range props {
+				alt := append([]RailItem(nil), prop...)
+				for _, rest := range props[i+1:] {
+					alt = append(alt, Optional(Sequence(append([]RailItem{Terminal(",")}, rest...)...)))
+				}
+				alts = append(alts, Sequence(alt...))
+			}
+			items = append(items, Optional(choiceOf(alts)))
+		default:
+			for i, prop := range props {
+				switch {
+				case i < first:
+					items = append(items, Optional(Sequence(append(prop, Terminal(","))...)))
+				case i == first:
+					items = append(items, prop...)
+				case required[names[i]]:
+					items = append(append(items, Terminal(",")), prop...)
+				default:
+					items = append(items, Optional(Sequence(append([]RailItem{Terminal(",")}, prop...)...)))
+				}
+			}
+		}
+	} else {
+		value := NonTerminal("value")
+		if schema.Additional != nil {
+			var err error
+			if value, err = jsonSchemaItem(schema.Additional); err != nil {
+				return nil, fmt.Errorf("additionalProperties: %v", err)
+			}
+		}
+		items = append(items, ZeroOrMore(
+			Sequence(NonTerminal("string"), Terminal(":"), value),
+			ZeroOrMoreRepeat(Terminal(","))))
+	}
+
+	return Sequence(append(items, Terminal("}"))...), nil
+}
+
+func jsonSchemaArray(schema *jsonSchema) (RailItem, error) {
+	items := []RailItem{Terminal("[")}
+
+	if schema.PrefixItems != nil || bytes.HasPrefix(bytes.TrimSpace(schema.Items), []byte("[")) {
+		tuple := schema.PrefixItems
+		if tuple == nil {
+			if err := json.Unmarshal(schema.Items, &tuple); err != nil {
+				return nil, fmt.Errorf("items: %v", err)
+			}
+		}
+		for i, data := range tuple {
+			item, err := jsonSchemaItem(data)
+			if err != nil {
+				return nil, fmt.Errorf("items: %v", err)
+			}
+			if i > 0 {
+				items = append(items, Terminal(","))
+			}
+			items = append(items, item)
+		}
+		return Sequence(append(items, Terminal("]"))...), nil
+	}
+
+	item := NonTerminal("value")
+	if schema.Items != nil {
+		var err error
+		if item, err = jsonSchemaItem(schema.Items); err != nil {
+			return nil, fmt.Errorf("items: %v", err)
+		}
+	}
+	switch {
+	case schema.MinItems == 0:
+		items = append(items, ZeroOrMore(item, ZeroOrMoreRepeat(Terminal(","))))
+	case schema.MinItems == 1:
+		items = append(items, OneOrMore(item, OneOrMoreRepeat(Terminal(","))))
+	default:
+		items = append(items, OneOrMore(item, OneOrMoreRepeat(Terminal(","))),
+			Comment(fmt.Sprintf("at least %d items", schema.MinItems)))
+	}
+	return Sequence(append(items, Terminal("]"))...), nil
+}
+
+// jsonSchemaValue returns the compact form of a JSON value.
+func jsonSchemaValue(data json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
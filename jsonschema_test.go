@@ -0,0 +1,98 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testJSONSchema = `{
+  "title": "Order",
+  "type": "object",
+  "properties": {
+    "id": {"type": "integer"},
+    "status": {"enum": ["open", "closed", null]},
+    "customer": {"$ref": "#/definitions/Customer"},
+    "lines": {"type": "array", "items": {"$ref": "#/definitions/Line"}, "minItems": 1},
+    "note": {"type": ["string", "null"]}
+  },
+  "required": ["id", "lines"],
+  "definitions": {
+    "Customer": {
+      "properties": {"name": {"type": "string"}},
+      "additionalProperties": {"type": "boolean"}
+    },
+    "Line": {
+      "oneOf": [
+        {"type": "array", "items": [{"type": "string"}, {"type": "number"}]},
+        {"type": "object", "additionalProperties": true}
+      ]
+    }
+  }
+}`
+
+func TestJSONSchema(t *testing.T) {
+	prods, err := ReadJSONSchema(strings.NewReader(testJSONSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, prod := range prods {
+		names = append(names, prod.Name)
+		add(prod.Name, Diagram(prod.Item))
+	}
+	if got := strings.Join(names, " "); got != "Order Customer Line" {
+		t.Fatalf("got productions %q", got)
+	}
+
+	order := prods[0].Item.(*sequence)
+	if text := order.items[1].(*terminal).text; text != `"id"` {
+		t.Fatalf("expected the required id property first, got %q", text)
+	}
+	ref := order.items[5].(*choice).items[1].(*sequence).items[3].(*nonTerminal)
+	if ref.text != "Customer" || ref.href != "#Customer" {
+		t.Fatalf("got reference %q to %q", ref.text, ref.href)
+	}
+
+	for _, test := range []struct {
+		schema string
+		want   RailItem
+	}{
+		{`{"properties": {"a": {"type": "string"}, "b": {"type": "string"}}, "required": ["b"]}`, Sequence(
+			Terminal("{"),
+			Optional(Sequence(Terminal(`"a"`), Terminal(":"), NonTerminal("string"), Terminal(","))),
+			Terminal(`"b"`), Terminal(":"), NonTerminal("string"),
+			Terminal("}"),
+		)},
+		{`{"properties": {"a": {"type": "string"}, "b": {"type": "string"}, "c": {"type": "string"}}}`, Sequence(
+			Terminal("{"),
+			Optional(Choice(0,
+				Sequence(
+					Terminal(`"a"`), Terminal(":"), NonTerminal("string"),
+					Optional(Sequence(Terminal(","), Terminal(`"b"`), Terminal(":"), NonTerminal("string"))),
+					Optional(Sequence(Terminal(","), Terminal(`"c"`), Terminal(":"), NonTerminal("string"))),
+				),
+				Sequence(
+					Terminal(`"b"`), Terminal(":"), NonTerminal("string"),
+					Optional(Sequence(Terminal(","), Terminal(`"c"`), Terminal(":"), NonTerminal("string"))),
+				),
+				Sequence(Terminal(`"c"`), Terminal(":"), NonTerminal("string")),
+			)),
+			Terminal("}"),
+		)},
+	} {
+		prods, err := ReadJSONSchema(strings.NewReader(test.schema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(prods[0].Item, test.want) {
+			t.Fatalf("got\n%v\nwant\n%v", prods[0].Item, test.want)
+		}
+	}
+
+	for _, bad := range []string{`[]`, `{"type": "wat"}`, `{"properties": {"a": {"type": 1}}}`} {
+		if _, err := ReadJSONSchema(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+}
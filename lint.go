@@ -0,0 +1,184 @@
+package railroad
+
+import "fmt"
+
+// Problem is an issue Lint found in a rule of a grammar. Line is the Line of
+// the rule's production, or zero if unknown.
+type Problem struct {
+	Rule    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, p.Rule, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Rule, p.Message)
+}
+
+// Lint returns the problems with the rules of the grammar, in the order of the
+// rules: references to rules that aren't defined, rules defined more than
+// once, rules that can't be reached from the start rule, rules that can never
+// finish because every alternative refers to a rule that can't, rules that
+// are identical to an earlier one, empty rules, and Choices with the same
+// alternative twice, more than one empty alternative, or one that isn't a
+// plain Skip. In grammars read from text, an empty alternative is one written
+// as nothing, such as "a | | b", rather than with %empty in yacc.
+func Lint(g *Grammar) []Problem {
+	var problems []Problem
+	report := func(prod Production, format string, args ...interface{}) {
+		problems = append(problems, Problem{
+			Rule:    prod.Name,
+			Line:    prod.Line,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	rules := g.rules()
+	reachable := make(map[string]bool)
+	if len(g.Productions) > 0 {
+		queue := []string{g.Productions[0].Name}
+		reachable[queue[0]] = true
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			for _, ref := range g.References(name) {
+				if _, ok := rules[ref]; ok && !reachable[ref] {
+					reachable[ref] = true
+					queue = append(queue, ref)
+				}
+			}
+		}
+	}
+	productive := g.productive()
+
+	for i, prod := range g.Productions {
+		if rules[prod.Name] != i {
+			first := g.Productions[rules[prod.Name]]
+			if first.Line > 0 {
+				report(prod, "defined more than once, first on line %d", first.Line)
+			} else {
+				report(prod, "defined more than once")
+			}
+			continue
+		}
+		for _, ref := range references(prod.Item) {
+			if _, ok := rules[ref]; !ok {
+				report(prod, "undefined rule %q", ref)
+			}
+		}
+		if !reachable[prod.Name] {
+			report(prod, "unreachable from %q", g.Productions[0].Name)
+		}
+		if !productive[prod.Name] {
+			report(prod, "never finishes")
+		}
+		for _, prev := range g.Productions[:i] {
+			if prev.Name != prod.Name && Equal(prev.Item, prod.Item) {
+				report(prod, "identical to %q", prev.Name)
+				break
+			}
+		}
+		if lintEmpty(prod.Item) {
+			report(prod, "empty")
+		} else {
+			Walk(prod.Item, func(item RailItem) bool {
+				if ch, ok := item.(*choice); ok {
+					empty, padded, twice := 0, false, false
+					for i, alt := range ch.items {
+						if lintEmpty(alt) {
+							empty++
+							padded = padded || KindOf(alt) != KindSkip
+							continue
+						}
+						for _, prev := range ch.items[:i] {
+							twice = twice || Equal(prev, alt)
+						}
+					}
+					if twice {
+						report(prod, "choice with the same alternative twice")
+					}
+					if ch.empty > 0 {
+						empty += ch.empty - 1
+					}
+					switch {
+					case empty > 1:
+						report(prod, "choice with %d empty alternatives", empty)
+					case padded:
+						report(prod, "choice with an empty alternative that isn't a Skip")
+					case ch.bare:
+						report(prod, "choice with an empty alternative")
+					}
+				}
+				return true
+			})
+		}
+	}
+	return problems
+}
+
+// productive returns the names of the rules that can finish, computed by
+// marking rules until no more can be marked. References to undefined rules
+// are assumed to finish.
+func (g *Grammar) productive() map[string]bool {
+	rules, productive := g.rules(), make(map[string]bool)
+	var finishes func(item RailItem) bool
+	finishes = func(item RailItem) bool {
+		switch item := item.(type) {
+		case *nonTerminal:
+			_, ok := rules[item.text]
+			return !ok || productive[item.text]
+		case *choice, *optionalSequence:
+			for _, child := range Children(item) {
+				if finishes(child) {
+					return true
+				}
+			}
+			return false
+		case *multipleChoice:
+			all := MultipleChoiceType(item.type_) == MultipleChoiceAll
+			for _, child := range item.items {
+				if finishes(child) != all {
+					return !all
+				}
+			}
+			return all
+		case *oneOrMore:
+			return finishes(item.item)
+		}
+		for _, child := range Children(item) {
+			if !finishes(child) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, prod := range g.Productions {
+			if !productive[prod.Name] && finishes(prod.Item) {
+				productive[prod.Name] = true
+				changed = true
+			}
+		}
+	}
+	return productive
+}
+
+// lintEmpty reports whether the item only matches the empty sequence.
+func lintEmpty(item RailItem) bool {
+	switch item.(type) {
+	case *skip:
+		return true
+	case *sequence, *stack, *group:
+		for _, child := range Children(item) {
+			if !lintEmpty(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	prods, err := ReadGBNF(strings.NewReader(`
+root   ::= item ("," item)* | missing
+item   ::= "x" | loop | ("a" | ) | empty
+loop   ::= "(" loop ")"
+copy   ::= "(" loop ")"
+empty  ::= ""
+item   ::= "y"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prods = append(prods,
+		Production{Name: "any", Item: MultipleChoice(0, MultipleChoiceAny, NonTerminal("loop"), Terminal("z"))},
+		Production{Name: "all", Item: MultipleChoice(0, MultipleChoiceAll, NonTerminal("loop"), Terminal("z"))},
+		Production{Name: "padded", Item: Choice(0, Terminal("a"), Skip(), Sequence(Skip(), Skip()))},
+		Production{Name: "blank", Item: Skip()},
+	)
+
+	var got []string
+	for _, problem := range Lint(NewGrammar(prods...)) {
+		got = append(got, problem.String())
+	}
+	want := []string{
+		`line 2: root: undefined rule "missing"`,
+		`line 3: item: choice with an empty alternative`,
+		`line 4: loop: never finishes`,
+		`line 5: copy: unreachable from "root"`,
+		`line 5: copy: never finishes`,
+		`line 5: copy: identical to "loop"`,
+		`line 7: item: defined more than once, first on line 3`,
+		`any: unreachable from "root"`,
+		`all: unreachable from "root"`,
+		`all: never finishes`,
+		`padded: unreachable from "root"`,
+		`padded: choice with 2 empty alternatives`,
+		`blank: unreachable from "root"`,
+		`blank: empty`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	prods, err = ReadYacc(strings.NewReader("%%\na: | b | ;\nb: %empty | 'x' | 'x' ;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, problem := range Lint(NewGrammar(prods...)) {
+		got = append(got, problem.String())
+	}
+	want = []string{
+		`line 2: a: choice with 2 empty alternatives`,
+		`line 3: b: choice with the same alternative twice`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	shared := Terminal("a")
+	problems := Lint(NewGrammar(Production{Name: "a", Item: shared, Line: 1}, Production{Name: "a", Item: shared, Line: 2}))
+	if len(problems) != 1 || problems[0].String() != "line 2: a: defined more than once, first on line 1" {
+		t.Fatalf("got %v", problems)
+	}
+
+	if problems := Lint(NewGrammar(Production{Name: "a", Item: Optional(Terminal("a"))})); len(problems) != 0 {
+		t.Fatalf("got %v", problems)
+	}
+}
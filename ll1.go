@@ -0,0 +1,341 @@
+package railroad
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EndOfInput is the token that follows the start rule.
+const EndOfInput = "end of input"
+
+// Analysis is the nullable, FIRST and FOLLOW sets of the rules of a grammar,
+// as a parser that reads the text of Terminals as tokens sees them. A
+// NonTerminal without a rule is taken to be a token named by its text, and
+// Comments match nothing.
+type Analysis struct {
+	g        *Grammar
+	nullable map[string]bool
+	first    map[string]map[string]bool
+	follow   map[string]map[string]bool
+}
+
+// Analyze computes the sets of the rules of the grammar.
+func Analyze(g *Grammar) *Analysis {
+	an := &Analysis{
+		g:        g,
+		nullable: make(map[string]bool),
+		first:    make(map[string]map[string]bool),
+		follow:   make(map[string]map[string]bool),
+	}
+	for _, prod := range g.Productions {
+		an.first[prod.Name] = make(map[string]bool)
+		an.follow[prod.Name] = make(map[string]bool)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, prod := range g.Productions {
+			if !an.nullable[prod.Name] && an.itemNullable(prod.Item) {
+				an.nullable[prod.Name] = true
+				changed = true
+			}
+			if addTokens(an.first[prod.Name], an.itemFirst(prod.Item)) {
+				changed = true
+			}
+		}
+	}
+
+	if len(g.Productions) > 0 {
+		an.follow[g.Productions[0].Name][EndOfInput] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, prod := range g.Productions {
+			an.walk(prod.Item, an.follow[prod.Name], func(item RailItem, follow map[string]bool) {
+				if nt, ok := item.(*nonTerminal); ok && an.follow[nt.text] != nil {
+					if addTokens(an.follow[nt.text], follow) {
+						changed = true
+					}
+				}
+			})
+		}
+	}
+	return an
+}
+
+// Nullable reports whether the named rule can match no tokens.
+func (an *Analysis) Nullable(rule string) bool { return an.nullable[rule] }
+
+// First returns the sorted tokens that can start the named rule.
+func (an *Analysis) First(rule string) []string { return sortedTokens(an.first[rule]) }
+
+// Follow returns the sorted tokens that can follow the named rule, including
+// EndOfInput after the start rule.
+func (an *Analysis) Follow(rule string) []string { return sortedTokens(an.follow[rule]) }
+
+// Conflict is a decision point in a rule where the next token doesn't decide
+// which way an LL(1) parser should go. Item is the Choice, including those
+// built by Optional and ZeroOrMore, or the OneOrMore deciding whether to
+// repeat, and Tokens are the sorted tokens that allow more than one way.
+type Conflict struct {
+	Rule   string
+	Line   int
+	Item   RailItem
+	Tokens []string
+}
+
+func (c Conflict) String() string {
+	kind := "choice"
+	if ch, ok := c.Item.(*choice); ok && len(ch.items) == 2 && KindOf(ch.items[0]) == KindSkip {
+		kind = "optional"
+		if KindOf(ch.items[1]) == KindOneOrMore {
+			kind = "zero or more"
+		}
+	} else if KindOf(c.Item) == KindOneOrMore {
+		kind = "repetition"
+	}
+	if c.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s conflicts on %s", c.Line, c.Rule, kind, quoteTokens(c.Tokens))
+	}
+	return fmt.Sprintf("%s: %s conflicts on %s", c.Rule, kind, quoteTokens(c.Tokens))
+}
+
+// Highlight marks the conflict in the diagram of its rule with the "conflict"
+// class and a tooltip listing the tokens. It must be called before the item
+// is drawn, and does nothing if the item is already marked.
+func (c Conflict) Highlight() {
+	var di *diagramItem
+	switch item := c.Item.(type) {
+	case *choice:
+		di = item.diagramItem
+	case *oneOrMore:
+		di = item.diagramItem
+	default:
+		return
+	}
+	for _, class := range strings.Fields(di.attrs["class"]) {
+		if class == "conflict" {
+			return
+		}
+	}
+	di.attrs["class"] = strings.TrimSpace(di.attrs["class"] + " conflict")
+	di.addChild(newDiagramText("title", "LL(1) conflict on "+quoteTokens(c.Tokens), nil))
+}
+
+// Conflicts returns the LL(1) conflicts in the rules of the grammar, in the
+// order of the rules. The decisions to enter and to repeat a ZeroOrMore are
+// reported together as one conflict of its Choice.
+func (an *Analysis) Conflicts() []Conflict {
+	var conflicts []Conflict
+	for _, prod := range an.g.Productions {
+		inner := make(map[*oneOrMore]bool) // reported with their ZeroOrMore
+		an.walk(prod.Item, an.follow[prod.Name], func(item RailItem, follow map[string]bool) {
+			var tokens map[string]bool
+			switch item := item.(type) {
+			case *choice:
+				var ways []map[string]bool
+				for _, alt := range item.items {
+					ways = append(ways, an.predict([]RailItem{alt}, follow))
+				}
+				tokens = overlap(ways)
+				if len(item.items) == 2 && KindOf(item.items[0]) == KindSkip {
+					if rep, ok := item.items[1].(*oneOrMore); ok {
+						addTokens(tokens, overlap(an.repeatWays(rep, follow)))
+						inner[rep] = true
+					}
+				}
+			case *oneOrMore:
+				if inner[item] {
+					return
+				}
+				tokens = overlap(an.repeatWays(item, follow))
+			default:
+				return
+			}
+			if len(tokens) > 0 {
+				conflicts = append(conflicts, Conflict{
+					Rule:   prod.Name,
+					Line:   prod.Line,
+					Item:   item,
+					Tokens: sortedTokens(tokens),
+				})
+			}
+		})
+	}
+	return conflicts
+}
+
+// repeatWays returns the tokens that decide to repeat a OneOrMore and those
+// that decide to leave it.
+func (an *Analysis) repeatWays(item *oneOrMore, follow map[string]bool) []map[string]bool {
+	return []map[string]bool{an.predict([]RailItem{item.rep, item.item}, follow), follow}
+}
+
+// overlap returns the tokens in more than one of the sets.
+func overlap(sets []map[string]bool) map[string]bool {
+	tokens := make(map[string]bool)
+	for i := range sets {
+		for j := range sets[:i] {
+			for token := range sets[i] {
+				if sets[j][token] {
+					tokens[token] = true
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// walk calls fn with every item in the item and the tokens that can follow
+// it, given the tokens that can follow the item.
+func (an *Analysis) walk(item RailItem, follow map[string]bool, fn func(RailItem, map[string]bool)) {
+	fn(item, follow)
+	switch item := item.(type) {
+	case *sequence:
+		an.walkSequence(item.items, follow, fn)
+	case *stack:
+		an.walkSequence(item.items, follow, fn)
+	case *choice:
+		for _, alt := range item.items {
+			an.walk(alt, follow, fn)
+		}
+	case *optionalSequence, *multipleChoice:
+		// any of the items can follow another
+		rest := copyTokens(follow)
+		for _, child := range Children(item) {
+			addTokens(rest, an.itemFirst(child))
+		}
+		for _, child := range Children(item) {
+			an.walk(child, rest, fn)
+		}
+	case *oneOrMore:
+		// the item can be followed by leaving or repeating
+		rest := copyTokens(follow)
+		addTokens(rest, an.predict([]RailItem{item.rep, item.item}, nil))
+		an.walk(item.item, rest, fn)
+		an.walk(item.rep, an.predict([]RailItem{item.item}, rest), fn)
+	case *group:
+		an.walk(item.item, follow, fn)
+	}
+}
+
+func (an *Analysis) walkSequence(items []RailItem, follow map[string]bool, fn func(RailItem, map[string]bool)) {
+	for i, item := range items {
+		an.walk(item, an.predict(items[i+1:], follow), fn)
+	}
+}
+
+// predict returns the tokens that can start the items, and the tokens of
+// follow if the items can match no tokens.
+func (an *Analysis) predict(items []RailItem, follow map[string]bool) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, item := range items {
+		addTokens(tokens, an.itemFirst(item))
+		if !an.itemNullable(item) {
+			return tokens
+		}
+	}
+	addTokens(tokens, follow)
+	return tokens
+}
+
+func (an *Analysis) itemNullable(item RailItem) bool {
+	switch item := item.(type) {
+	case *terminal:
+		return false
+	case *nonTerminal:
+		if an.first[item.text] == nil {
+			return false
+		}
+		return an.nullable[item.text]
+	case *choice, *optionalSequence:
+		for _, child := range Children(item) {
+			if an.itemNullable(child) {
+				return true
+			}
+		}
+		return false
+	case *multipleChoice:
+		all := MultipleChoiceType(item.type_) == MultipleChoiceAll
+		for _, child := range item.items {
+			if an.itemNullable(child) != all {
+				return !all
+			}
+		}
+		return all
+	case *oneOrMore:
+		return an.itemNullable(item.item)
+	}
+	for _, child := range Children(item) {
+		if !an.itemNullable(child) {
+			return false
+		}
+	}
+	return true
+}
+
+func (an *Analysis) itemFirst(item RailItem) map[string]bool {
+	switch item := item.(type) {
+	case *terminal:
+		return map[string]bool{item.text: true}
+	case *nonTerminal:
+		if first := an.first[item.text]; first != nil {
+			return first
+		}
+		return map[string]bool{item.text: true}
+	case *sequence:
+		return an.predict(item.items, nil)
+	case *stack:
+		return an.predict(item.items, nil)
+	case *oneOrMore:
+		return an.predict([]RailItem{item.item, item.rep}, nil)
+	}
+	tokens := make(map[string]bool)
+	for _, child := range Children(item) {
+		addTokens(tokens, an.itemFirst(child))
+	}
+	return tokens
+}
+
+// addTokens adds the tokens to the set, reporting whether it added any.
+func addTokens(set, tokens map[string]bool) bool {
+	added := false
+	for token := range tokens {
+		if !set[token] {
+			set[token] = true
+			added = true
+		}
+	}
+	return added
+}
+
+func copyTokens(tokens map[string]bool) map[string]bool {
+	set := make(map[string]bool, len(tokens))
+	addTokens(set, tokens)
+	return set
+}
+
+func sortedTokens(tokens map[string]bool) []string {
+	list := make([]string, 0, len(tokens))
+	for token := range tokens {
+		list = append(list, token)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// quoteTokens returns the tokens quoted and separated by commas, except for
+// EndOfInput.
+func quoteTokens(tokens []string) string {
+	quoted := make([]string, len(tokens))
+	for i, token := range tokens {
+		if token == EndOfInput {
+			quoted[i] = token
+		} else {
+			quoted[i] = strconv.Quote(token)
+		}
+	}
+	return strings.Join(quoted, ", ")
+}
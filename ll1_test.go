@@ -0,0 +1,81 @@
+package railroad
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	g := NewGrammar(
+		Production{Name: "stmt", Item: Choice(0,
+			Sequence(Terminal("if"), NonTerminal("expr"), NonTerminal("stmt"), Optional(Sequence(Terminal("else"), NonTerminal("stmt")))),
+			Sequence(NonTerminal("IDENT"), Terminal("="), NonTerminal("expr")),
+			Sequence(NonTerminal("expr"), Terminal(";")),
+		), Line: 1},
+		Production{Name: "expr", Item: Sequence(
+			NonTerminal("term"),
+			ZeroOrMore(Sequence(Choice(0, Terminal("+"), Terminal("-")), NonTerminal("term"))),
+		), Line: 2},
+		Production{Name: "term", Item: Sequence(Optional(Terminal("-")), Choice(0, NonTerminal("IDENT"), NonTerminal("NUMBER"))), Line: 3},
+		Production{Name: "list", Item: OneOrMore(Optional(Terminal("x")), OneOrMoreRepeat(Optional(Terminal(",")))), Line: 4},
+		Production{Name: "stars", Item: Sequence(ZeroOrMore(Terminal("*")), Terminal("*")), Line: 5},
+		Production{Name: "pluses", Item: Sequence(OneOrMore(Terminal("+")), Terminal("+")), Line: 6},
+	)
+	an := Analyze(g)
+
+	for _, test := range []struct {
+		rule          string
+		nullable      bool
+		first, follow []string
+	}{
+		{"stmt", false, []string{"-", "IDENT", "NUMBER", "if"}, []string{"else", EndOfInput}},
+		{"expr", false, []string{"-", "IDENT", "NUMBER"}, []string{"-", ";", "IDENT", "NUMBER", "else", EndOfInput, "if"}},
+		{"term", false, []string{"-", "IDENT", "NUMBER"}, []string{"+", "-", ";", "IDENT", "NUMBER", "else", EndOfInput, "if"}},
+		{"list", true, []string{",", "x"}, []string{}},
+	} {
+		if got := an.Nullable(test.rule); got != test.nullable {
+			t.Errorf("%s: got nullable %v", test.rule, got)
+		}
+		if got := an.First(test.rule); !reflect.DeepEqual(got, test.first) {
+			t.Errorf("%s: got first %q", test.rule, got)
+		}
+		if got := an.Follow(test.rule); !reflect.DeepEqual(got, test.follow) {
+			t.Errorf("%s: got follow %q", test.rule, got)
+		}
+	}
+
+	var got []string
+	conflicts := an.Conflicts()
+	for _, conflict := range conflicts {
+		got = append(got, conflict.String())
+	}
+	want := []string{
+		`line 1: stmt: choice conflicts on "IDENT"`,
+		`line 1: stmt: optional conflicts on "else"`,
+		`line 2: expr: zero or more conflicts on "-"`,
+		`line 4: list: optional conflicts on "x"`,
+		`line 4: list: optional conflicts on ","`,
+		`line 5: stars: zero or more conflicts on "*"`,
+		`line 6: pluses: repetition conflicts on "+"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, conflict := range conflicts {
+		conflict.Highlight()
+		conflict.Highlight()
+	}
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<g class="conflict">`+"\n"+`<title>LL(1) conflict on "IDENT"</title>`) {
+		t.Fatalf("expected a highlighted conflict in\n%s", buf.String())
+	}
+	if n := strings.Count(buf.String(), "<title>"); n != len(conflicts) {
+		t.Fatalf("got %d titles for %d conflicts", n, len(conflicts))
+	}
+}
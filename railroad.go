@@ -55,6 +55,20 @@ var (
         stroke:black;
         fill:hsl(120,100%,90%);
     }
+    svg.railroad-diagram g.char-class rect{
+        fill:hsl(200,100%,90%);
+    }
+    svg.railroad-diagram g.anchor rect{
+        fill:hsl(0,0%,90%);
+    }
+    svg.railroad-diagram rect.group-box{
+        stroke:gray;
+        stroke-dasharray:10 5;
+        fill:none;
+    }
+    svg.railroad-diagram g.conflict > path{
+        stroke:hsl(0,80%,50%);
+    }
 `
 )
 
@@ -585,6 +599,11 @@ type choice struct {
 	*diagramItem
 	def   int
 	items []RailItem
+
+	// empty is the number of empty alternatives choiceOf merged into the
+	// leading Skip, and bare whether any was written as nothing, for Lint.
+	empty int
+	bare  bool
 }
 
 func Choice(default_ int, items ...RailItem) RailItem {
@@ -629,9 +648,11 @@ func (self *choice) format(x, y, width float64) RailItem {
 	innerWidth := self.width - ConfigArcRadius*4
 	def := self.items[self.def]
 
-	above := self.items[:self.def]
-	for i, j := 0, len(above)-1; i < j; i, j = i+1, j-1 {
-		above[i], above[j] = above[j], above[i]
+	// the items above the default, nearest first, without reordering the
+	// items themselves
+	above := make([]RailItem, self.def)
+	for i := range above {
+		above[i] = self.items[self.def-1-i]
 	}
 	var distanceFromY float64
 	if len(above) > 0 {
@@ -753,9 +774,11 @@ func (self *multipleChoice) format(x, y, width float64) RailItem {
 
 	def := self.items[self.def]
 
-	above := self.items[:self.def]
-	for i, j := 0, len(above)-1; i < j; i, j = i+1, j-1 {
-		above[i], above[j] = above[j], above[i]
+	// the items above the default, nearest first, without reordering the
+	// items themselves
+	above := make([]RailItem, self.def)
+	for i := range above {
+		above[i] = self.items[self.def-1-i]
 	}
 	distanceFromY := 0.0
 	if len(above) > 0 {
@@ -994,11 +1017,26 @@ func (self *end) format(x, y, width float64) RailItem {
 
 type terminal struct {
 	*diagramItem
-	text string
+	text  string
+	class string
+}
+
+type TerminalOption struct {
+	class *string
 }
 
-func Terminal(text string) RailItem {
-	di := newDiagramItem("g", a{"class": "terminal"})
+// TerminalClass adds a class to the terminal so it can be styled.
+func TerminalClass(class string) TerminalOption { return TerminalOption{class: &class} }
+
+func Terminal(text string, options ...TerminalOption) RailItem {
+	var class string
+	for _, opt := range options {
+		if opt.class != nil {
+			class = *opt.class
+		}
+	}
+
+	di := newDiagramItem("g", a{"class": strings.TrimSpace("terminal " + class)})
 	di.width = float64(len(text))*ConfigCharacterAdvance + 20
 	di.up = 11
 	di.down = 11
@@ -1006,6 +1044,7 @@ func Terminal(text string) RailItem {
 	return &terminal{
 		diagramItem: di,
 		text:        text,
+		class:       class,
 	}
 }
 
@@ -1035,9 +1074,24 @@ func (self *terminal) format(x, y, width float64) RailItem {
 type nonTerminal struct {
 	*diagramItem
 	text string
+	href string
+}
+
+type NonTerminalOption struct {
+	href *string
 }
 
-func NonTerminal(text string) RailItem {
+// NonTerminalHref links the nonterminal's text to href.
+func NonTerminalHref(href string) NonTerminalOption { return NonTerminalOption{href: &href} }
+
+func NonTerminal(text string, options ...NonTerminalOption) RailItem {
+	var href string
+	for _, opt := range options {
+		if opt.href != nil {
+			href = *opt.href
+		}
+	}
+
 	di := newDiagramItem("g", a{"class": "non-terminal"})
 	di.width = float64(len(text))*ConfigCharacterAdvance + 20
 	di.up = 11
@@ -1046,6 +1100,7 @@ func NonTerminal(text string) RailItem {
 	return &nonTerminal{
 		diagramItem: di,
 		text:        text,
+		href:        href,
 	}
 }
 
@@ -1061,11 +1116,17 @@ func (self *nonTerminal) format(x, y, width float64) RailItem {
 		"width":  fmt.Sprint(self.width),
 		"height": fmt.Sprint(self.up + self.down),
 	}))
-	// TODO href
-	self.addChild(newDiagramText("text", self.text, a{
+	text := newDiagramText("text", self.text, a{
 		"x": fmt.Sprint(x + float64(int(width/2))),
 		"y": fmt.Sprint(y + 4),
-	}))
+	})
+	if self.href != "" {
+		link := newDiagramItem("a", a{"xlink:href": self.href})
+		link.addChild(text)
+		self.addChild(link)
+	} else {
+		self.addChild(text)
+	}
 
 	return self
 }
@@ -1103,6 +1164,71 @@ func (self *comment) format(x, y, width float64) RailItem {
 	return self
 }
 
+type group struct {
+	*diagramItem
+	item  RailItem
+	label RailItem
+	boxUp float64
+}
+
+// Group draws a labeled box around the item.
+func Group(item RailItem, label string) RailItem {
+	var lab RailItem
+	if label != "" {
+		lab = Comment(label)
+	}
+
+	di := newDiagramItem("g", nil)
+	itemWidth := item.getWidth()
+	if item.getNeedsSpace() {
+		itemWidth += 20
+	}
+	di.width = max(itemWidth, ConfigArcRadius*2)
+	if lab != nil {
+		di.width = max(di.width, lab.getWidth())
+	}
+	di.height = item.getHeight()
+	boxUp := max(item.getUp()+ConfigVerticalSeparation, ConfigArcRadius)
+	di.up = boxUp
+	if lab != nil {
+		di.up += lab.getUp() + lab.getHeight() + lab.getDown()
+	}
+	di.down = max(item.getDown()+ConfigVerticalSeparation, ConfigArcRadius)
+	di.needsSpace = true
+	return &group{
+		diagramItem: di,
+		item:        item,
+		label:       lab,
+		boxUp:       boxUp,
+	}
+}
+
+func (self *group) format(x, y, width float64) RailItem {
+	leftGap, rightGap := determineGaps(width, self.width)
+
+	self.addChild(newPath(x, y).h(leftGap))
+	self.addChild(newPath(x+leftGap+self.width, y+self.height).h(rightGap))
+	x += leftGap
+
+	self.addChild(newDiagramItem("rect", a{
+		"x":      fmt.Sprint(x),
+		"y":      fmt.Sprint(y - self.boxUp),
+		"width":  fmt.Sprint(self.width),
+		"height": fmt.Sprint(self.boxUp + self.height + self.down),
+		"rx":     fmt.Sprint(ConfigArcRadius),
+		"ry":     fmt.Sprint(ConfigArcRadius),
+		"class":  "group-box",
+	}))
+	self.addChild(self.item.format(x, y, self.width))
+	if self.label != nil {
+		self.addChild(self.label.format(x,
+			y-(self.boxUp+self.label.getDown()+self.label.getHeight()),
+			self.label.getWidth()))
+	}
+
+	return self
+}
+
 type skip struct {
 	*diagramItem
 }
@@ -187,3 +187,17 @@ func TestBasic(t *testing.T) {
 		ZeroOrMore(NonTerminal(`Component value`)),
 		Text(`)`)))
 }
+
+func TestChoiceOrder(t *testing.T) {
+	ch := Choice(2, Terminal("a"), Terminal("b"), Terminal("c"), Terminal("d"))
+	mc := MultipleChoice(2, MultipleChoiceAny, Terminal("a"), Terminal("b"), Terminal("c"), Terminal("d"))
+	add("choice order", Diagram(ch, mc))
+
+	for _, items := range [][]RailItem{ch.(*choice).items, mc.(*multipleChoice).items} {
+		for i, want := range []string{"a", "b", "c", "d"} {
+			if got := items[i].(*terminal).text; got != want {
+				t.Fatalf("got %q for item %d after drawing, want %q", got, i, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package railroad
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Production is a named rule, such as a nonterminal read from a grammar file.
+// Line is the line of the source it was defined on, or zero if unknown.
+type Production struct {
+	Name string
+	Item RailItem
+	Line int
+}
+
+// sequenceOf is Sequence but tolerates zero or one items.
+func sequenceOf(items []RailItem) RailItem {
+	switch len(items) {
+	case 0:
+		return Skip()
+	case 1:
+		return items[0]
+	}
+	return Sequence(items...)
+}
+
+// choiceOf is Choice but tolerates a single alternative, and hoists empty
+// alternatives to a single Skip above the rest like Optional does. The
+// number it hoisted is kept on the Choice so Lint can still report them.
+func choiceOf(items []RailItem) RailItem {
+	var rest []RailItem
+	empty := 0
+	for _, item := range items {
+		if _, ok := item.(*skip); ok {
+			empty++
+			continue
+		}
+		rest = append(rest, item)
+	}
+	switch {
+	case len(rest) == 0:
+		return Skip()
+	case empty == 0 && len(rest) == 1:
+		return rest[0]
+	case empty == 0:
+		return Choice(0, rest...)
+	}
+	ch := Choice(1, append([]RailItem{Skip()}, rest...)...).(*choice)
+	ch.empty, ch.bare = empty, true
+	return ch
+}
+
+// repeatItem returns the item repeated between min and max times, or at
+// least min times if max is -1, with a Comment for any count that isn't
+// plainly optional or repeated.
+func repeatItem(item RailItem, min, max int) RailItem {
+	var count string
+	switch {
+	case max == 0:
+		return Skip()
+	case min == 1 && max == 1:
+		return item
+	case min == 0 && max == 1:
+		return Optional(item)
+	case min == 0 && max == -1:
+		return ZeroOrMore(item)
+	case min == 1 && max == -1:
+		return OneOrMore(item)
+	case max == -1:
+		count = fmt.Sprintf("%d+ times", min)
+	case min == max:
+		count = fmt.Sprintf("%d times", min)
+	case min == 0:
+		count = fmt.Sprintf("at most %d times", max)
+	default:
+		count = fmt.Sprintf("%d-%d times", min, max)
+	}
+	if min == 0 {
+		return ZeroOrMore(item, ZeroOrMoreRepeat(Comment(count)))
+	}
+	return OneOrMore(item, OneOrMoreRepeat(Comment(count)))
+}
+
+// jsonObject decodes a JSON object, returning its keys in the order they
+// appear as well as the values.
+func jsonObject(data []byte) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected a JSON object, found %v", tok)
+	}
+
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,121 @@
+package railroad
+
+// Unrecurse returns a copy of the grammar with its directly left or right
+// recursive rules drawn as repetitions. A rule of the form
+//
+//	list = item | list sep item
+//
+// or its right recursive mirror becomes OneOrMore(item, OneOrMoreRepeat(sep)),
+// and other rules whose recursive alternatives all start, or all end, with the
+// rule become the base alternatives followed, or preceded, by a ZeroOrMore of
+// the rest of the recursive ones. Rules that refer to themselves in other ways
+// are left as they are.
+func (g *Grammar) Unrecurse() *Grammar {
+	prods := make([]Production, len(g.Productions))
+	for i, prod := range g.Productions {
+		prod.Item = Clone(prod.Item)
+		if item := unrecurse(prod.Name, prod.Item); item != nil {
+			prod.Item = item
+		}
+		prods[i] = prod
+	}
+	return NewGrammar(prods...)
+}
+
+// unrecurse returns the repetition the rule describes, or nil if it isn't
+// directly left or right recursive.
+func unrecurse(name string, item RailItem) RailItem {
+	alts := []RailItem{item}
+	if ch, ok := item.(*choice); ok {
+		alts = ch.items
+	}
+	self := func(item RailItem) bool {
+		nt, ok := item.(*nonTerminal)
+		return ok && nt.text == name
+	}
+
+	var bases, lefts, rights [][]RailItem
+	for _, alt := range alts {
+		items := branchItems(alt)
+		n := len(items)
+		for j, item := range items {
+			if j != 0 && j != n-1 && refersTo(item, name) {
+				return nil
+			}
+			if (j == 0 || j == n-1) && !self(item) && refersTo(item, name) {
+				return nil
+			}
+		}
+		switch {
+		case n > 0 && self(items[0]) && self(items[n-1]):
+			return nil
+		case n > 0 && self(items[0]):
+			lefts = append(lefts, items[1:])
+		case n > 0 && self(items[n-1]):
+			rights = append(rights, items[:n-1])
+		default:
+			bases = append(bases, items)
+		}
+	}
+	if len(bases) == 0 || (len(lefts) == 0) == (len(rights) == 0) {
+		return nil
+	}
+
+	// list = item | list sep item  or  list = item | item sep list
+	if len(bases) == 1 && len(bases[0]) > 0 && len(lefts)+len(rights) == 1 {
+		base := bases[0]
+		if len(lefts) == 1 && len(lefts[0]) >= len(base) {
+			n := len(lefts[0]) - len(base)
+			if itemsEqual(lefts[0][n:], base) {
+				return OneOrMore(sequenceOf(base), OneOrMoreRepeat(sequenceOf(lefts[0][:n])))
+			}
+		}
+		if len(rights) == 1 && len(rights[0]) >= len(base) {
+			n := len(base)
+			if itemsEqual(rights[0][:n], base) {
+				return OneOrMore(sequenceOf(base), OneOrMoreRepeat(sequenceOf(rights[0][n:])))
+			}
+		}
+	}
+
+	var baseItems, repItems []RailItem
+	for _, base := range bases {
+		baseItems = append(baseItems, sequenceOf(base))
+	}
+	for _, rep := range append(lefts, rights...) {
+		repItems = append(repItems, sequenceOf(rep))
+	}
+	rep := ZeroOrMore(choiceOf(repItems))
+	base := choiceOf(baseItems)
+	if _, ok := base.(*skip); ok {
+		return rep
+	}
+	if len(lefts) > 0 {
+		return Sequence(base, rep)
+	}
+	return Sequence(rep, base)
+}
+
+// refersTo reports whether the item contains a NonTerminal with the name.
+func refersTo(item RailItem, name string) bool {
+	found := false
+	Walk(item, func(item RailItem) bool {
+		if nt, ok := item.(*nonTerminal); ok && nt.text == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func itemsEqual(x, y []RailItem) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !Equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package railroad
+
+import "testing"
+
+func TestUnrecurse(t *testing.T) {
+	g := NewGrammar(
+		Production{Name: "left", Item: Choice(0,
+			NonTerminal("item"),
+			Sequence(NonTerminal("left"), Terminal(","), NonTerminal("item")))},
+		Production{Name: "right", Item: Choice(0,
+			NonTerminal("item"),
+			Sequence(NonTerminal("item"), Terminal(","), NonTerminal("right")))},
+		Production{Name: "plain", Item: Choice(0,
+			NonTerminal("item"),
+			Sequence(NonTerminal("plain"), NonTerminal("item")))},
+		Production{Name: "empty", Item: Optional(Sequence(NonTerminal("empty"), NonTerminal("item")))},
+		Production{Name: "expr", Item: Choice(0,
+			NonTerminal("term"),
+			Sequence(NonTerminal("expr"), Terminal("+"), NonTerminal("term")),
+			Sequence(NonTerminal("expr"), Terminal("-"), NonTerminal("term")))},
+		Production{Name: "prefix", Item: Choice(0,
+			NonTerminal("atom"),
+			Sequence(Terminal("-"), NonTerminal("prefix")))},
+		Production{Name: "both", Item: Choice(0,
+			NonTerminal("item"),
+			Sequence(NonTerminal("both"), Terminal("+"), NonTerminal("both")))},
+		Production{Name: "nested", Item: Choice(0,
+			NonTerminal("item"),
+			Sequence(Terminal("("), NonTerminal("nested"), Terminal(")")))},
+	)
+
+	want := map[string]RailItem{
+		"left":  OneOrMore(NonTerminal("item"), OneOrMoreRepeat(Terminal(","))),
+		"right": OneOrMore(NonTerminal("item"), OneOrMoreRepeat(Terminal(","))),
+		"plain": OneOrMore(NonTerminal("item"), OneOrMoreRepeat(Skip())),
+		"empty": ZeroOrMore(NonTerminal("item")),
+		"expr": Sequence(NonTerminal("term"), ZeroOrMore(Choice(0,
+			Sequence(Terminal("+"), NonTerminal("term")),
+			Sequence(Terminal("-"), NonTerminal("term"))))),
+		"prefix": Sequence(ZeroOrMore(Terminal("-")), NonTerminal("atom")),
+	}
+
+	out := g.Unrecurse()
+	for i, prod := range out.Productions {
+		w, ok := want[prod.Name]
+		if !ok {
+			w = g.Productions[i].Item
+		}
+		if !Equal(prod.Item, w) {
+			t.Errorf("%s:\ngot  %v\nwant %v", prod.Name, prod.Item, w)
+		}
+	}
+	if KindOf(g.Productions[0].Item) != KindChoice {
+		t.Fatal("expected the grammar to be unchanged")
+	}
+	add("unrecurse", Diagram(out.Productions[4].Item))
+}
@@ -0,0 +1,92 @@
+package railroad
+
+import (
+	"fmt"
+	"regexp/syntax"
+)
+
+// ParseRegexp parses a Go regular expression and returns a diagram of the
+// text it matches. Character classes and anchors are drawn as Terminals with
+// the "char-class" and "anchor" classes, counted repetitions as a OneOrMore
+// or ZeroOrMore with a Comment for the count, and capture groups as Groups
+// labeled with their name or number.
+func ParseRegexp(pattern string) (RailItem, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return regexpItem(re), nil
+}
+
+var regexpAnchors = map[syntax.Op]string{
+	syntax.OpBeginLine:      "start of line",
+	syntax.OpEndLine:        "end of line",
+	syntax.OpBeginText:      "start of text",
+	syntax.OpEndText:        "end of text",
+	syntax.OpWordBoundary:   "word boundary",
+	syntax.OpNoWordBoundary: "not word boundary",
+}
+
+func regexpItem(re *syntax.Regexp) RailItem {
+	subs := func() []RailItem {
+		items := make([]RailItem, 0, len(re.Sub))
+		for _, sub := range re.Sub {
+			items = append(items, regexpItem(sub))
+		}
+		return items
+	}
+
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return Comment("no match")
+
+	case syntax.OpEmptyMatch:
+		return Skip()
+
+	case syntax.OpLiteral:
+		item := Terminal(string(re.Rune))
+		if re.Flags&syntax.FoldCase != 0 {
+			return Group(item, "any case")
+		}
+		return item
+
+	case syntax.OpCharClass:
+		return Terminal(re.String(), TerminalClass("char-class"))
+
+	case syntax.OpAnyCharNotNL:
+		return Terminal("any character but newline", TerminalClass("char-class"))
+
+	case syntax.OpAnyChar:
+		return Terminal("any character", TerminalClass("char-class"))
+
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return Terminal(regexpAnchors[re.Op], TerminalClass("anchor"))
+
+	case syntax.OpCapture:
+		label := re.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", re.Cap)
+		}
+		return Group(regexpItem(re.Sub[0]), label)
+
+	case syntax.OpStar:
+		return ZeroOrMore(regexpItem(re.Sub[0]))
+
+	case syntax.OpPlus:
+		return OneOrMore(regexpItem(re.Sub[0]))
+
+	case syntax.OpQuest:
+		return Optional(regexpItem(re.Sub[0]))
+
+	case syntax.OpRepeat:
+		return repeatItem(regexpItem(re.Sub[0]), re.Min, re.Max)
+
+	case syntax.OpConcat:
+		return sequenceOf(subs())
+
+	case syntax.OpAlternate:
+		return choiceOf(subs())
+	}
+	panic(fmt.Sprintf("unknown regexp op %v", re.Op))
+}
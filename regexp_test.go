@@ -0,0 +1,37 @@
+package railroad
+
+import "testing"
+
+func TestRegexp(t *testing.T) {
+	for _, pattern := range []string{
+		`^[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}$`,
+		`(?P<year>\d{4})-(\d{2})-(\d{2})`,
+		`(?i)select|insert|update`,
+		`\bfoo(bar)?\B.*x{3}y{0,2}z{1,3}`,
+		`\A(?s:.)?\z|[^\n]`,
+		`a{0}`,
+	} {
+		item, err := ParseRegexp(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		add(pattern, Diagram(item))
+	}
+
+	item, err := ParseRegexp(`(?P<digits>[0-9]{1,6})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, ok := item.(*group)
+	if !ok || g.label.(*comment).text != "digits" {
+		t.Fatalf("expected a group labeled digits, got %T", item)
+	}
+	rep := g.item.(*oneOrMore)
+	if rep.rep.(*comment).text != "1-6 times" || rep.item.(*terminal).class != "char-class" {
+		t.Fatalf("got repetition %+v", rep)
+	}
+
+	if _, err := ParseRegexp(`(`); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,147 @@
+package railroad
+
+// Simplify returns a copy of the item in its simplest equivalent form, built
+// with the constructors. It flattens Sequences in Sequences, Stacks in Stacks
+// and Choices in Choices, drops Skips from Sequences and Stacks, merges the
+// empty and duplicate alternatives of a Choice so an optional Choice draws
+// like Optional, replaces Sequences, Stacks, OptionalSequences and Choices of
+// a single item by the item, and collapses repetitions of repetitions, such as
+// Optional(Optional(x)), OneOrMore(OneOrMore(x)) and OneOrMore(Optional(x)).
+func Simplify(item RailItem) RailItem {
+	return Rewrite(item, simplifyItem)
+}
+
+// simplifyItem simplifies an item whose children are already simplified.
+func simplifyItem(item RailItem) RailItem {
+	switch item := item.(type) {
+	case *sequence:
+		items := simplifyItems(item.items, func(item RailItem) []RailItem {
+			if seq, ok := item.(*sequence); ok {
+				return seq.items
+			}
+			return nil
+		})
+		return sequenceOf(items)
+
+	case *stack:
+		items := simplifyItems(item.items, func(item RailItem) []RailItem {
+			if st, ok := item.(*stack); ok {
+				return st.items
+			}
+			return nil
+		})
+		if len(items) < 2 {
+			return sequenceOf(items)
+		}
+		return Stack(items...)
+
+	case *optionalSequence:
+		if len(item.items) == 1 {
+			return item.items[0]
+		}
+
+	case *choice:
+		return simplifyChoice(item)
+
+	case *oneOrMore:
+		if _, ok := item.rep.(*skip); !ok {
+			break
+		}
+		switch inner := item.item.(type) {
+		case *oneOrMore:
+			// (x+)+ is x+
+			if KindOf(inner.rep) == KindSkip {
+				return inner
+			}
+		case *choice:
+			// (x?)+ is x*
+			if KindOf(inner.items[0]) != KindSkip {
+				break
+			}
+			x := inner.items[1]
+			if len(inner.items) > 2 {
+				def := inner.def - 1
+				if def < 0 {
+					def = 0
+				}
+				x = Choice(def, inner.items[1:]...)
+			}
+			which := 1
+			if inner.def == 0 {
+				which = 0
+			}
+			return Choice(which, Skip(), simplifyItem(OneOrMore(x)))
+		}
+	}
+	return item
+}
+
+// simplifyItems returns the items with Skips dropped and the items of the
+// items flatten returns items for in their place.
+func simplifyItems(items []RailItem, flatten func(RailItem) []RailItem) []RailItem {
+	var out []RailItem
+	for _, item := range items {
+		if inner := flatten(item); inner != nil {
+			out = append(out, inner...)
+		} else if KindOf(item) != KindSkip {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// simplifyChoice flattens the Choices in the Choice, merges its duplicate
+// alternatives, and puts a single Skip first if any alternative is empty,
+// keeping the default.
+func simplifyChoice(item *choice) RailItem {
+	var alts []RailItem
+	def := 0
+	for i, alt := range item.items {
+		if inner, ok := alt.(*choice); ok {
+			if i == item.def {
+				def = len(alts) + inner.def
+			}
+			alts = append(alts, inner.items...)
+			continue
+		}
+		if i == item.def {
+			def = len(alts)
+		}
+		alts = append(alts, alt)
+	}
+
+	var rest []RailItem
+	empty, skipDefault, restDef := false, false, 0
+	for i, alt := range alts {
+		if KindOf(alt) == KindSkip {
+			empty = true
+			skipDefault = skipDefault || i == def
+			continue
+		}
+		index := len(rest)
+		for j, prev := range rest {
+			if Equal(prev, alt) {
+				index = j
+				break
+			}
+		}
+		if index == len(rest) {
+			rest = append(rest, alt)
+		}
+		if i == def {
+			restDef = index
+		}
+	}
+
+	switch {
+	case len(rest) == 0:
+		return Skip()
+	case !empty && len(rest) == 1:
+		return rest[0]
+	case !empty:
+		return Choice(restDef, rest...)
+	case skipDefault:
+		return Choice(0, append([]RailItem{Skip()}, rest...)...)
+	}
+	return Choice(restDef+1, append([]RailItem{Skip()}, rest...)...)
+}
@@ -0,0 +1,63 @@
+package railroad
+
+import "testing"
+
+func TestSimplify(t *testing.T) {
+	for _, test := range []struct {
+		in, want RailItem
+	}{
+		{
+			Sequence(Sequence(Terminal("a")), Skip(), Sequence(Terminal("b"), Terminal("c"))),
+			Sequence(Terminal("a"), Terminal("b"), Terminal("c")),
+		},
+		{
+			Stack(Stack(Terminal("a"), Skip()), Sequence(Terminal("b"), Skip())),
+			Stack(Terminal("a"), Terminal("b")),
+		},
+		{Sequence(Skip(), Skip()), Skip()},
+		{Stack(Terminal("a")), Terminal("a")},
+		{OptionalSequence(Terminal("a")), Terminal("a")},
+		{
+			Choice(1, Terminal("a"), Choice(1, Terminal("b"), Terminal("c")), Terminal("a")),
+			Choice(2, Terminal("a"), Terminal("b"), Terminal("c")),
+		},
+		{
+			Choice(2, Terminal("a"), Skip(), Terminal("b"), Skip()),
+			Choice(2, Skip(), Terminal("a"), Terminal("b")),
+		},
+		{Choice(0, Skip(), Skip()), Skip()},
+		{Choice(0, Terminal("a"), Terminal("a")), Terminal("a")},
+		{Optional(Optional(Terminal("a"))), Optional(Terminal("a"))},
+		{Optional(Optional(Terminal("a"), OptionalSkip(true))), Optional(Terminal("a"), OptionalSkip(true))},
+		{Optional(ZeroOrMore(Terminal("a"))), ZeroOrMore(Terminal("a"))},
+		{OneOrMore(OneOrMore(Terminal("a"))), OneOrMore(Terminal("a"))},
+		{
+			OneOrMore(OneOrMore(Terminal("a"), OneOrMoreRepeat(Terminal(",")))),
+			OneOrMore(OneOrMore(Terminal("a"), OneOrMoreRepeat(Terminal(",")))),
+		},
+		{OneOrMore(Optional(Terminal("a"))), ZeroOrMore(Terminal("a"))},
+		{ZeroOrMore(ZeroOrMore(Terminal("a"))), ZeroOrMore(Terminal("a"))},
+		{
+			OneOrMore(Optional(Choice(0, Terminal("a"), Terminal("b")))),
+			ZeroOrMore(Choice(0, Terminal("a"), Terminal("b"))),
+		},
+		{
+			OneOrMore(Optional(Terminal("a")), OneOrMoreRepeat(Terminal(","))),
+			OneOrMore(Optional(Terminal("a")), OneOrMoreRepeat(Terminal(","))),
+		},
+		{
+			Group(Sequence(Sequence(Terminal("a"))), "label"),
+			Group(Terminal("a"), "label"),
+		},
+	} {
+		if got := Simplify(test.in); !Equal(got, test.want) {
+			t.Errorf("Simplify(%v):\ngot  %v\nwant %v", test.in, got, test.want)
+		}
+	}
+
+	add("simplify", Diagram(Simplify(Sequence(
+		Sequence(Terminal("select")),
+		Optional(Optional(Choice(0, Terminal("all"), Choice(0, Terminal("distinct"), Skip())))),
+		OneOrMore(OneOrMore(NonTerminal("column"))),
+	))))
+}
@@ -0,0 +1,296 @@
+package railroad
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var structKeyedTagRegexp = regexp.MustCompile(`^\s*\w+:"`)
+
+// StructGrammar returns a diagram of the grammar declared by the struct tags
+// of the type of v, in the style of the participle parser library, followed by
+// one for every struct type it captures with @@. v may be a value of the type
+// or its reflect.Type. The grammar is read from the parser tag of each field,
+// or the whole tag if it isn't in the key:"value" form, and fields without one
+// are ignored. Literals are drawn as Terminals, token types like @Ident as
+// Terminals with the "char-class" class, and captured struct types as
+// NonTerminals named by the type.
+func StructGrammar(v interface{}) ([]Production, error) {
+	typ, ok := v.(reflect.Type)
+	if !ok {
+		typ = reflect.TypeOf(v)
+	}
+	if typ == nil || structElem(typ).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("struct grammar: %v is not a struct", typ)
+	}
+	typ = structElem(typ)
+
+	var prods []Production
+	seen := map[reflect.Type]bool{typ: true}
+	queue := []reflect.Type{typ}
+	for len(queue) > 0 {
+		typ := queue[0]
+		queue = queue[1:]
+
+		var toks []structToken
+		structTokens(typ, &toks)
+		p := &structTagParser{toks: toks}
+		item := Skip()
+		if len(toks) > 0 {
+			item = p.expression()
+		}
+		if tok := p.next(); tok != "" {
+			p.errorf("unexpected %q", tok)
+		}
+		if p.err != nil {
+			return nil, fmt.Errorf("struct grammar: %s: %v", structName(typ), p.err)
+		}
+		prods = append(prods, Production{Name: structName(typ), Item: item})
+
+		for _, ref := range p.refs {
+			if !seen[ref] {
+				seen[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+	}
+	return prods, nil
+}
+
+// structToken is a token of a grammar along with the field it belongs to.
+type structToken struct {
+	text  string
+	field reflect.StructField
+}
+
+// structTokens appends the tokens of the grammars of the fields of the struct
+// to toks. Like participle, the grammars of the fields are joined, so an
+// expression may span fields, and embedded structs without a grammar are
+// inlined.
+func structTokens(typ reflect.Type, toks *[]structToken) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		grammar, ok := field.Tag.Lookup("parser")
+		if !ok && field.Tag != "" && !structKeyedTagRegexp.MatchString(string(field.Tag)) {
+			grammar, ok = string(field.Tag), true
+		}
+
+		if !ok {
+			if field.Anonymous && structElem(field.Type).Kind() == reflect.Struct {
+				structTokens(structElem(field.Type), toks)
+			}
+			continue
+		}
+		if grammar == "-" {
+			continue
+		}
+		for _, text := range lexStructTag(grammar) {
+			*toks = append(*toks, structToken{text: text, field: field})
+		}
+	}
+}
+
+// structElem returns the type with pointers and slices removed.
+func structElem(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func structName(typ reflect.Type) string {
+	if typ.Name() == "" {
+		return typ.String()
+	}
+	return typ.Name()
+}
+
+// lexStructTag splits a grammar into literals, names and punctuation.
+func lexStructTag(grammar string) []string {
+	var toks []string
+	for i := 0; i < len(grammar); {
+		switch c := grammar[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(grammar) && grammar[j] != c {
+				if grammar[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(grammar) {
+				j++
+			}
+			toks = append(toks, grammar[i:j])
+			i = j
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(grammar) && (grammar[j] == '_' || grammar[j] >= 'a' && grammar[j] <= 'z' ||
+				grammar[j] >= 'A' && grammar[j] <= 'Z' || grammar[j] >= '0' && grammar[j] <= '9') {
+				j++
+			}
+			toks = append(toks, grammar[i:j])
+			i = j
+		case strings.HasPrefix(grammar[i:], "@@"):
+			toks = append(toks, "@@")
+			i += 2
+		default:
+			toks = append(toks, grammar[i:i+1])
+			i++
+		}
+	}
+	return toks
+}
+
+type structTagParser struct {
+	toks []structToken
+	last reflect.StructField
+	refs []reflect.Type
+	err  error
+}
+
+func (p *structTagParser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%s: %s", p.last.Name, fmt.Sprintf(format, args...))
+	}
+}
+
+func (p *structTagParser) peek() string {
+	if len(p.toks) == 0 || p.err != nil {
+		return ""
+	}
+	return p.toks[0].text
+}
+
+func (p *structTagParser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.last = p.toks[0].field
+		p.toks = p.toks[1:]
+	}
+	return tok
+}
+
+func (p *structTagParser) expect(tok string) {
+	if p.peek() != tok {
+		p.errorf("expected %q, found %q", tok, p.peek())
+		return
+	}
+	p.next()
+}
+
+func (p *structTagParser) expression() RailItem {
+	alts := []RailItem{p.sequence()}
+	for p.peek() == "|" {
+		p.next()
+		alts = append(alts, p.sequence())
+	}
+	return choiceOf(alts)
+}
+
+func (p *structTagParser) sequence() RailItem {
+	var items []RailItem
+	for {
+		switch p.peek() {
+		case "", "|", ")", "]", "}":
+			if len(items) == 0 {
+				p.errorf("expected an expression, found %q", p.peek())
+			}
+			return sequenceOf(items)
+		}
+		items = append(items, p.term())
+	}
+}
+
+func (p *structTagParser) term() RailItem {
+	item := p.unary()
+	switch p.peek() {
+	case "?":
+		item = Optional(item)
+	case "*":
+		item = ZeroOrMore(item)
+	case "+":
+		item = OneOrMore(item)
+	default:
+		return item
+	}
+	p.next()
+	return item
+}
+
+func (p *structTagParser) unary() RailItem {
+	switch tok := p.peek(); tok {
+	case "@@":
+		p.next()
+		typ := structElem(p.last.Type)
+		if typ.Kind() == reflect.Struct {
+			p.refs = append(p.refs, typ)
+		}
+		return NonTerminal(structName(typ))
+	case "@":
+		p.next()
+		return p.unary()
+	case "!", "~":
+		p.next()
+		return Group(p.unary(), "any token but")
+	}
+	return p.atom()
+}
+
+func (p *structTagParser) atom() RailItem {
+	tok := p.next()
+	if tok == "" {
+		p.errorf("unexpected end of grammar")
+		return Skip()
+	}
+
+	switch tok[0] {
+	case '"', '\'', '`':
+		if len(tok) < 2 || tok[len(tok)-1] != tok[0] {
+			p.errorf("unterminated literal %s", tok)
+			return Skip()
+		}
+		if p.peek() == ":" {
+			p.next()
+			p.atom()
+		}
+		return Terminal(tok[1 : len(tok)-1])
+
+	case '(':
+		label := ""
+		if p.peek() == "?" && len(p.toks) > 1 && (p.toks[1].text == "=" || p.toks[1].text == "!") {
+			label = "followed by"
+			if p.toks[1].text == "!" {
+				label = "not followed by"
+			}
+			p.next()
+			p.next()
+		}
+		item := p.expression()
+		p.expect(")")
+		if label != "" {
+			return Group(item, label)
+		}
+		return item
+
+	case '[':
+		item := p.expression()
+		p.expect("]")
+		return Optional(item)
+
+	case '{':
+		item := p.expression()
+		p.expect("}")
+		return ZeroOrMore(item)
+	}
+
+	if tok[0] == '_' || tok[0] >= 'a' && tok[0] <= 'z' || tok[0] >= 'A' && tok[0] <= 'Z' {
+		return Terminal(tok, TerminalClass("char-class"))
+	}
+	p.errorf("unexpected %q", tok)
+	return Skip()
+}
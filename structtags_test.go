@@ -0,0 +1,94 @@
+package railroad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testPos struct {
+	Offset int
+}
+
+type testSelect struct {
+	Pos testPos
+
+	Columns []*testColumn `parser:"'SELECT' @@ ( ',' @@ )*"`
+	From    string        `parser:"'FROM' @Ident"`
+	Where   *testExpr     `parser:"( 'WHERE' @@ )?" json:"where"`
+	Limit   int           `parser:"[ 'LIMIT' @Int ]"`
+	Ignored string        `json:"ignored"`
+}
+
+type testColumn struct {
+	Star bool   `parser:"  @'*'"`
+	Name string `parser:"| @Ident ( '.' @Ident )*"`
+}
+
+type testExpr struct {
+	testOperand
+	Op    string    `parser:"( @( '=' | '<' | '>' ) | @'!' '=' )"`
+	Right testValue `parser:"@@"`
+}
+
+type testOperand struct {
+	Left string `parser:"@Ident (?! '(')"`
+}
+
+type testValue struct {
+	Number *float64 `parser:"@Float | @Int"`
+	String *string  `parser:"| @String | 'null':Keyword"`
+	Other  string   `parser:"| !';'"`
+}
+
+func TestStructGrammar(t *testing.T) {
+	prods, err := StructGrammar(&testSelect{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, prod := range prods {
+		names = append(names, prod.Name)
+		add(prod.Name, Diagram(prod.Item))
+	}
+	if got := strings.Join(names, " "); got != "testSelect testColumn testExpr testValue" {
+		t.Fatalf("got productions %q", got)
+	}
+	sel := prods[0].Item.(*sequence)
+	if len(sel.items) != 7 || sel.items[1].(*nonTerminal).text != "testColumn" {
+		t.Fatalf("got select %+v", sel.items)
+	}
+	if _, ok := prods[1].Item.(*choice); !ok {
+		t.Fatalf("expected the column fields to form a choice, got %T", prods[1].Item)
+	}
+	if len(prods[2].Item.(*sequence).items) != 4 {
+		t.Fatalf("expected the embedded operand to be inlined")
+	}
+
+	if _, err := StructGrammar(reflect.TypeOf(testColumn{})); err != nil {
+		t.Fatal(err)
+	}
+	bare := reflect.StructOf([]reflect.StructField{{
+		Name: "X",
+		Type: reflect.TypeOf(""),
+		Tag:  `"x" @Ident`,
+	}})
+	prods, err = StructGrammar(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods[0].Item.(*sequence).items) != 2 {
+		t.Fatalf("expected the bare tag to be read as a grammar")
+	}
+
+	if _, err := StructGrammar(1); err == nil {
+		t.Fatal("expected an error for a non-struct")
+	}
+	type bad struct {
+		X string `parser:"( 'a'"`
+	}
+	if _, err := StructGrammar(bad{}); err == nil {
+		t.Fatal("expected an error for a bad grammar")
+	}
+}
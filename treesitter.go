@@ -0,0 +1,151 @@
+package railroad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+type treeSitterRule struct {
+	Type    string            `json:"type"`
+	Name    string            `json:"name"`
+	Value   json.RawMessage   `json:"value"`
+	Named   bool              `json:"named"`
+	Content *treeSitterRule   `json:"content"`
+	Members []*treeSitterRule `json:"members"`
+}
+
+// ReadTreeSitter reads the grammar.json generated by tree-sitter, producing a
+// diagram for every rule in the order they are defined. Fields are drawn as
+// Groups labeled with the field name, and precedence and token wrappers are
+// drawn as their contents.
+func ReadTreeSitter(r io.Reader) ([]Production, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var grammar struct {
+		Rules json.RawMessage `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &grammar); err != nil {
+		return nil, fmt.Errorf("tree-sitter: %v", err)
+	}
+	if grammar.Rules == nil {
+		return nil, fmt.Errorf("tree-sitter: missing rules")
+	}
+	names, rules, err := jsonObject(grammar.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("tree-sitter: rules: %v", err)
+	}
+
+	prods := make([]Production, 0, len(names))
+	for _, name := range names {
+		var rule treeSitterRule
+		if err := json.Unmarshal(rules[name], &rule); err != nil {
+			return nil, fmt.Errorf("tree-sitter: rule %s: %v", name, err)
+		}
+		item, err := treeSitterItem(&rule)
+		if err != nil {
+			return nil, fmt.Errorf("tree-sitter: rule %s: %v", name, err)
+		}
+		prods = append(prods, Production{Name: name, Item: item})
+	}
+	return prods, nil
+}
+
+func treeSitterItem(rule *treeSitterRule) (RailItem, error) {
+	if rule == nil {
+		return nil, fmt.Errorf("missing rule")
+	}
+
+	content := func() (RailItem, error) { return treeSitterItem(rule.Content) }
+	members := func() ([]RailItem, error) {
+		items := make([]RailItem, 0, len(rule.Members))
+		for _, member := range rule.Members {
+			item, err := treeSitterItem(member)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	value := func() (string, error) {
+		var value string
+		if err := json.Unmarshal(rule.Value, &value); err != nil {
+			return "", fmt.Errorf("%s value: %v", rule.Type, err)
+		}
+		return value, nil
+	}
+
+	switch rule.Type {
+	case "BLANK":
+		return Skip(), nil
+
+	case "STRING":
+		text, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return Terminal(text), nil
+
+	case "PATTERN":
+		text, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return Terminal(text, TerminalClass("char-class")), nil
+
+	case "SYMBOL":
+		return NonTerminal(rule.Name), nil
+
+	case "SEQ":
+		items, err := members()
+		if err != nil {
+			return nil, err
+		}
+		return sequenceOf(items), nil
+
+	case "CHOICE":
+		items, err := members()
+		if err != nil {
+			return nil, err
+		}
+		return choiceOf(items), nil
+
+	case "REPEAT", "REPEAT1", "OPTIONAL":
+		item, err := content()
+		if err != nil {
+			return nil, err
+		}
+		switch rule.Type {
+		case "REPEAT":
+			return ZeroOrMore(item), nil
+		case "REPEAT1":
+			return OneOrMore(item), nil
+		}
+		return Optional(item), nil
+
+	case "PREC", "PREC_LEFT", "PREC_RIGHT", "PREC_DYNAMIC", "TOKEN", "IMMEDIATE_TOKEN":
+		return content()
+
+	case "FIELD":
+		item, err := content()
+		if err != nil {
+			return nil, err
+		}
+		return Group(item, rule.Name), nil
+
+	case "ALIAS":
+		text, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if rule.Named {
+			return NonTerminal(text), nil
+		}
+		return Terminal(text), nil
+	}
+	return nil, fmt.Errorf("unknown rule type %q", rule.Type)
+}
@@ -0,0 +1,81 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testTreeSitter = `{
+  "name": "json",
+  "rules": {
+    "document": {"type": "REPEAT", "content": {"type": "SYMBOL", "name": "_value"}},
+    "_value": {
+      "type": "CHOICE",
+      "members": [
+        {"type": "SYMBOL", "name": "object"},
+        {"type": "SYMBOL", "name": "number"},
+        {"type": "ALIAS", "content": {"type": "STRING", "value": "null"}, "named": true, "value": "null_value"}
+      ]
+    },
+    "object": {
+      "type": "SEQ",
+      "members": [
+        {"type": "STRING", "value": "{"},
+        {"type": "CHOICE", "members": [
+          {"type": "SEQ", "members": [
+            {"type": "SYMBOL", "name": "pair"},
+            {"type": "REPEAT", "content": {"type": "SEQ", "members": [
+              {"type": "STRING", "value": ","},
+              {"type": "SYMBOL", "name": "pair"}
+            ]}}
+          ]},
+          {"type": "BLANK"}
+        ]},
+        {"type": "STRING", "value": "}"}
+      ]
+    },
+    "pair": {
+      "type": "SEQ",
+      "members": [
+        {"type": "FIELD", "name": "key", "content": {"type": "SYMBOL", "name": "string"}},
+        {"type": "STRING", "value": ":"},
+        {"type": "FIELD", "name": "value", "content": {"type": "SYMBOL", "name": "_value"}}
+      ]
+    },
+    "number": {"type": "TOKEN", "content": {"type": "PREC", "value": 1,
+      "content": {"type": "PATTERN", "value": "\\d+"}}},
+    "string": {"type": "REPEAT1", "content": {"type": "IMMEDIATE_TOKEN",
+      "content": {"type": "PATTERN", "value": "[^\"]"}}}
+  },
+  "extras": [{"type": "PATTERN", "value": "\\s"}]
+}`
+
+func TestTreeSitter(t *testing.T) {
+	prods, err := ReadTreeSitter(strings.NewReader(testTreeSitter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, prod := range prods {
+		names = append(names, prod.Name)
+		add(prod.Name, Diagram(prod.Item))
+	}
+	if got := strings.Join(names, " "); got != "document _value object pair number string" {
+		t.Fatalf("got rules %q", got)
+	}
+	if g, ok := prods[3].Item.(*sequence).items[0].(*group); !ok || g.label.(*comment).text != "key" {
+		t.Fatalf("expected a field group, got %T", prods[3].Item.(*sequence).items[0])
+	}
+
+	for _, bad := range []string{
+		`{"rules": {"a": {"type": "WAT"}}}`,
+		`{"rules": {"a": {"type": "REPEAT"}}}`,
+		`{"rules": []}`,
+		`{}`,
+	} {
+		if _, err := ReadTreeSitter(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+}
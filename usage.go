@@ -0,0 +1,375 @@
+package railroad
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ParseUsage parses a command line usage pattern written in the conventions
+// of docopt and man page synopses, such as
+//
+//	git commit [-a | --all] [-m <msg>] [--] [<pathspec>...]
+//
+// Brackets mark optional parts, parentheses and braces required groups, "|"
+// alternatives and "..." repetition of what precedes it. Placeholders like
+// <msg> or MSG are drawn as NonTerminals, as is the docopt [options]
+// shortcut, and everything else as Terminals.
+func ParseUsage(usage string) (RailItem, error) {
+	p := &usageParser{toks: lexUsage(usage)}
+	item := p.alternatives()
+	if p.err == nil && len(p.toks) > 0 {
+		p.err = fmt.Errorf("usage: unexpected %q", p.toks[0])
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return item, nil
+}
+
+var usageUpperRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_-]*$`)
+
+// lexUsage splits a usage pattern into brackets, "|", "...", <placeholders>
+// and words.
+func lexUsage(usage string) []string {
+	var toks []string
+	for i := 0; i < len(usage); {
+		switch c := usage[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(usage[i:], "..."):
+			toks = append(toks, "...")
+			i += 3
+		case strings.IndexByte("[](){}|", c) >= 0:
+			toks = append(toks, usage[i:i+1])
+			i++
+		case c == '<':
+			j := strings.IndexByte(usage[i:], '>')
+			if j < 0 {
+				j = len(usage) - i - 1
+			}
+			toks = append(toks, usage[i:i+j+1])
+			i += j + 1
+		default:
+			j := i
+			for j < len(usage) && strings.IndexByte(" \t\n\r[](){}|<", usage[j]) < 0 &&
+				!strings.HasPrefix(usage[j:], "...") {
+				j++
+			}
+			toks = append(toks, usage[i:j])
+			i = j
+		}
+	}
+	return toks
+}
+
+type usageParser struct {
+	toks []string
+	err  error
+}
+
+func (p *usageParser) peek() string {
+	if len(p.toks) == 0 {
+		return ""
+	}
+	return p.toks[0]
+}
+
+func (p *usageParser) alternatives() RailItem {
+	alts := []RailItem{p.sequence()}
+	for p.err == nil && p.peek() == "|" {
+		p.toks = p.toks[1:]
+		alts = append(alts, p.sequence())
+	}
+	return choiceOf(alts)
+}
+
+func (p *usageParser) sequence() RailItem {
+	var items []RailItem
+	for p.err == nil {
+		var item RailItem
+		switch tok := p.peek(); tok {
+		case "", "|", "]", ")", "}":
+			return sequenceOf(items)
+
+		case "[", "(", "{":
+			close := map[string]string{"[": "]", "(": ")", "{": "}"}[tok]
+			p.toks = p.toks[1:]
+			item = p.alternatives()
+			if p.err != nil {
+				return Skip()
+			}
+			if p.peek() != close {
+				p.err = fmt.Errorf("usage: expected %q, found %q", close, p.peek())
+				return Skip()
+			}
+			p.toks = p.toks[1:]
+			if tok == "[" {
+				item = Optional(item)
+			}
+
+		case "...":
+			p.err = fmt.Errorf("usage: unexpected ...")
+			return Skip()
+
+		default:
+			p.toks = p.toks[1:]
+			item = usageWord(tok)
+		}
+
+		if p.peek() == "..." {
+			p.toks = p.toks[1:]
+			item = OneOrMore(item)
+		}
+		items = append(items, item)
+	}
+	return Skip()
+}
+
+func usageWord(word string) RailItem {
+	switch {
+	case strings.HasPrefix(word, "<") && strings.HasSuffix(word, ">"):
+		return NonTerminal(word[1 : len(word)-1])
+	case word == "options" || usageUpperRegexp.MatchString(word):
+		return NonTerminal(word)
+	}
+	return Terminal(word)
+}
+
+var (
+	roffFontRegexp   = regexp.MustCompile(`\\f(\[[^\]]*\]|\(..|.)`)
+	roffEscapeRegexp = regexp.MustCompile(`\\(\(..|\[[^\]]*\]|.)`)
+	roffWordRegexp   = regexp.MustCompile(`[^\s\[\](){}|<>]+`)
+	overstrikeRegexp = regexp.MustCompile(`.\x08`)
+)
+
+// ReadSynopsis reads the SYNOPSIS section of a man page, given either as roff
+// source or as formatted text, and returns a production for each command it
+// describes. Usages of the same command are combined in a Choice, and words
+// set in italics in roff are drawn as NonTerminals.
+func ReadSynopsis(r io.Reader) ([]Production, error) {
+	var (
+		lines   []string
+		roff    bool
+		in      bool
+		start   int
+		lineNum int
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := overstrikeRegexp.ReplaceAllString(scanner.Text(), "")
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			roff = true
+		}
+
+		heading, isHeading := "", false
+		if roff && (strings.HasPrefix(line, ".SH") || strings.HasPrefix(line, ".Sh")) {
+			heading, isHeading = strings.Trim(strings.TrimSpace(line[3:]), `"`), true
+		} else if !roff && line != "" && line[0] != ' ' && line[0] != '\t' {
+			heading, isHeading = strings.TrimSpace(line), true
+		}
+		if isHeading {
+			if in {
+				break
+			}
+			in = strings.EqualFold(heading, "SYNOPSIS")
+			start = lineNum + 1
+			continue
+		}
+		if in {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("synopsis: no SYNOPSIS section")
+	}
+
+	if roff {
+		lines = roffLines(lines)
+	}
+
+	var prods []Production
+	index := make(map[string]int)
+	var alts [][]RailItem
+	for _, usage := range splitUsages(lines) {
+		fields := strings.Fields(usage)
+		if len(fields) == 0 {
+			continue
+		}
+		item, err := ParseUsage(usage)
+		if err != nil {
+			return nil, fmt.Errorf("synopsis: %q: %v", usage, err)
+		}
+		name := fields[0]
+		i, ok := index[name]
+		if !ok {
+			i = len(prods)
+			index[name] = i
+			prods = append(prods, Production{Name: name, Line: start})
+			alts = append(alts, nil)
+		}
+		alts[i] = append(alts[i], item)
+	}
+	for i := range prods {
+		prods[i].Item = choiceOf(alts[i])
+	}
+	return prods, nil
+}
+
+// splitUsages joins the lines of a synopsis into usages, each starting with
+// the name of the command or at a line marked with a leading NUL.
+func splitUsages(lines []string) []string {
+	var usages []string
+	var name string
+	for _, line := range lines {
+		marked := strings.HasPrefix(line, "\x00")
+		line = strings.TrimPrefix(line, "\x00")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if name == "" {
+			name = fields[0]
+		}
+		if marked || fields[0] == name || len(usages) == 0 {
+			usages = append(usages, "")
+		}
+		usages[len(usages)-1] += " " + strings.TrimSpace(line)
+	}
+	return usages
+}
+
+// roffLines converts the lines of a synopsis written in roff into text,
+// marking the start of each .SY usage with a leading NUL.
+func roffLines(lines []string) []string {
+	var text []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, ".") && !strings.HasPrefix(line, "'") {
+			text = append(text, roffText(line))
+			continue
+		}
+		fields := roffArgs(line[1:])
+		if len(fields) == 0 {
+			continue
+		}
+		macro, args := fields[0], fields[1:]
+		switch macro {
+		case "B", "SM", "SB":
+			text = append(text, roffText(strings.Join(args, " ")))
+		case "I":
+			text = append(text, roffItalic(roffText(strings.Join(args, " "))))
+		case "BR", "BI", "IB", "IR", "RB", "RI":
+			// the arguments alternate between the two fonts
+			var line strings.Builder
+			for i, arg := range args {
+				if macro[i%2] == 'I' {
+					line.WriteString(roffItalic(roffText(arg)))
+				} else {
+					line.WriteString(roffText(arg))
+				}
+			}
+			text = append(text, line.String())
+		case "SY":
+			text = append(text, "\x00"+roffText(strings.Join(args, " ")))
+		case "OP":
+			if len(args) > 0 {
+				opt := roffText(args[0])
+				if len(args) > 1 {
+					opt += " " + roffItalic(roffText(strings.Join(args[1:], " ")))
+				}
+				text = append(text, "["+opt+"]")
+			}
+		}
+	}
+	return text
+}
+
+// roffArgs splits a request line into the macro and its arguments, honoring
+// double quotes.
+func roffArgs(line string) []string {
+	var args []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				args = append(args, line[1:])
+				break
+			}
+			args = append(args, line[1:end+1])
+			line = line[end+2:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		args = append(args, line[:end])
+		line = line[end:]
+	}
+	return args
+}
+
+// roffText removes font changes and replaces the common escapes in text,
+// marking the words in italics as placeholders.
+func roffText(text string) string {
+	var b strings.Builder
+	italic, prev := false, false
+	for {
+		end, m := len(text), roffFontRegexp.FindStringSubmatchIndex(text)
+		if m != nil {
+			end = m[0]
+		}
+		if italic {
+			b.WriteString(roffItalic(roffEscapes(text[:end])))
+		} else {
+			b.WriteString(roffEscapes(text[:end]))
+		}
+		if m == nil {
+			return b.String()
+		}
+		switch font := strings.Trim(text[m[2]:m[3]], "([]"); font {
+		case "P", "":
+			italic, prev = prev, italic
+		default:
+			italic, prev = strings.Contains(font, "I"), italic
+		}
+		text = text[m[1]:]
+	}
+}
+
+// roffItalic marks the words of text set in italics, which stand for what to
+// write in their place, as <placeholders>.
+func roffItalic(text string) string {
+	return roffWordRegexp.ReplaceAllStringFunc(text, func(word string) string {
+		name := strings.TrimSuffix(word, "...")
+		if name == "" {
+			return word
+		}
+		return "<" + name + ">" + word[len(name):]
+	})
+}
+
+// roffEscapes replaces the common escapes in text.
+func roffEscapes(text string) string {
+	return roffEscapeRegexp.ReplaceAllStringFunc(text, func(esc string) string {
+		switch esc {
+		case `\-`, `\(mi`, `\(hy`:
+			return "-"
+		case `\e`, `\\`:
+			return `\`
+		case `\ `, `\~`:
+			return " "
+		case `\(em`, `\(en`:
+			return "-"
+		case `\.`:
+			return "."
+		}
+		return ""
+	})
+}
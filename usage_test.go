@@ -0,0 +1,117 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testManRoff = `.TH GIT-COMMIT 1
+.SH NAME
+git-commit \- Record changes to the repository
+.SH SYNOPSIS
+.nf
+\fBgit commit\fR [\-a | \-\-interactive | \-\-patch] [\-s] [\-v] [\-u<mode>]
+           [\-\-cleanup=<mode>] [\-\-] [<pathspec>...]
+.fi
+.SH DESCRIPTION
+Stores the current contents of the index.
+`
+
+const testManMacros = `.TH TAR 1
+.SH SYNOPSIS
+.SY tar
+.OP \-f archive
+.I file ...
+.YS
+.SY tar
+\-\-list
+.BI \-C " dir"
+.YS
+.SH DESCRIPTION
+`
+
+const testManText = `LS(1)                     User Commands                    LS(1)
+
+NAME
+       ls - list directory contents
+
+SYNOPSIS
+       ls [OPTION]... [FILE]...
+       ls --version
+
+DESCRIPTION
+       List information about the FILEs.
+`
+
+func TestUsage(t *testing.T) {
+	item, err := ParseUsage(`git commit [-a | --all] [-m <msg>] [--] [<pathspec>...]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add("git commit", Diagram(item))
+
+	seq := item.(*sequence)
+	if len(seq.items) != 6 {
+		t.Fatalf("got %d items", len(seq.items))
+	}
+	msg := seq.items[3].(*choice).items[1].(*sequence)
+	if msg.items[0].(*terminal).text != "-m" || msg.items[1].(*nonTerminal).text != "msg" {
+		t.Fatalf("got message option %+v", msg.items)
+	}
+	if _, ok := seq.items[5].(*choice).items[1].(*oneOrMore); !ok {
+		t.Fatalf("expected repeated pathspecs")
+	}
+
+	item, err = ParseUsage(`naval_fate ship (new|move) <x> <y> [options] (--speed=<kn> | SPEED)...`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add("naval_fate", Diagram(item))
+
+	for _, bad := range []string{`a [b`, `a (b]`, `... a`, `a b)`} {
+		if _, err := ParseUsage(bad); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSynopsis(t *testing.T) {
+	prods, err := ReadSynopsis(strings.NewReader(testManRoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 1 || prods[0].Name != "git" || prods[0].Line != 5 {
+		t.Fatalf("got roff productions %+v", prods)
+	}
+	items := prods[0].Item.(*sequence).items
+	if len(items) != 9 || items[1].(*terminal).text != "commit" {
+		t.Fatalf("got %d roff items", len(items))
+	}
+	add("git", Diagram(prods[0].Item))
+
+	prods, err = ReadSynopsis(strings.NewReader(testManMacros))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Choice(0,
+		Sequence(Terminal("tar"), Optional(Sequence(Terminal("-f"), NonTerminal("archive"))), OneOrMore(NonTerminal("file"))),
+		Sequence(Terminal("tar"), Terminal("--list"), Terminal("-C"), NonTerminal("dir")),
+	)
+	if len(prods) != 1 || !Equal(prods[0].Item, want) {
+		t.Fatalf("got macro productions %+v", prods)
+	}
+	add("tar", Diagram(prods[0].Item))
+
+	prods, err = ReadSynopsis(strings.NewReader(testManText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prods) != 1 || prods[0].Name != "ls" || len(prods[0].Item.(*choice).items) != 2 {
+		t.Fatalf("got text productions %+v", prods)
+	}
+	add("ls", Diagram(prods[0].Item))
+
+	if _, err := ReadSynopsis(strings.NewReader("NAME\n    foo\n")); err == nil {
+		t.Fatal("expected an error without a synopsis")
+	}
+}
@@ -0,0 +1,185 @@
+package railroad
+
+// Kind is the kind of an item, named after the constructor that builds it.
+// Optional and ZeroOrMore build Choices, so they have no kinds of their own.
+type Kind string
+
+const (
+	KindSkip             Kind = "Skip"
+	KindTerminal         Kind = "Terminal"
+	KindNonTerminal      Kind = "NonTerminal"
+	KindComment          Kind = "Comment"
+	KindSequence         Kind = "Sequence"
+	KindStack            Kind = "Stack"
+	KindOptionalSequence Kind = "OptionalSequence"
+	KindChoice           Kind = "Choice"
+	KindMultipleChoice   Kind = "MultipleChoice"
+	KindOneOrMore        Kind = "OneOrMore"
+	KindGroup            Kind = "Group"
+)
+
+// KindOf returns the kind of the item, or "" if it isn't one of the kinds,
+// such as a diagram.
+func KindOf(item RailItem) Kind {
+	switch item.(type) {
+	case *skip:
+		return KindSkip
+	case *terminal:
+		return KindTerminal
+	case *nonTerminal:
+		return KindNonTerminal
+	case *comment:
+		return KindComment
+	case *sequence:
+		return KindSequence
+	case *stack:
+		return KindStack
+	case *optionalSequence:
+		return KindOptionalSequence
+	case *choice:
+		return KindChoice
+	case *multipleChoice:
+		return KindMultipleChoice
+	case *oneOrMore:
+		return KindOneOrMore
+	case *group:
+		return KindGroup
+	}
+	return ""
+}
+
+// TextOf returns the text of a Terminal, NonTerminal or Comment, or the label
+// of a Group.
+func TextOf(item RailItem) string {
+	switch item := item.(type) {
+	case *terminal:
+		return item.text
+	case *nonTerminal:
+		return item.text
+	case *comment:
+		return item.text
+	case *group:
+		if item.label != nil {
+			return item.label.(*comment).text
+		}
+	}
+	return ""
+}
+
+// Children returns the items directly contained in the item. The children
+// of a OneOrMore are its item and its repeat.
+func Children(item RailItem) []RailItem {
+	var items []RailItem
+	switch item := item.(type) {
+	case *sequence:
+		items = item.items
+	case *stack:
+		items = item.items
+	case *optionalSequence:
+		items = item.items
+	case *choice:
+		items = item.items
+	case *multipleChoice:
+		items = item.items
+	case *oneOrMore:
+		return []RailItem{item.item, item.rep}
+	case *group:
+		return []RailItem{item.item}
+	}
+	return append([]RailItem(nil), items...)
+}
+
+// Walk calls fn with the item and then, if it returns true, walks each of the
+// item's children.
+func Walk(item RailItem, fn func(RailItem) bool) {
+	if !fn(item) {
+		return
+	}
+	for _, child := range Children(item) {
+		Walk(child, fn)
+	}
+}
+
+// Rewrite returns a copy of the item built with the constructors, with fn
+// called on every item of the copy from the bottom up. Each item is replaced
+// by what fn returns, which may be the item itself. If fn returns nil, the
+// item is removed: it is dropped from lists of items, adjusting the default of
+// a Choice, and replaced by a Skip elsewhere. A list left without items is
+// removed as well. The original item is left unchanged, except that items
+// other than those the exported constructors return, such as the start and
+// end a Diagram adds, aren't copied and are passed to fn as they are.
+//
+// Since an item can only be drawn once, fn must not return the same item for
+// more than one place in the tree. Clone it instead.
+func Rewrite(item RailItem, fn func(RailItem) RailItem) RailItem {
+	var def int
+	var items []RailItem
+	switch item := item.(type) {
+	case *sequence, *stack, *optionalSequence, *choice, *multipleChoice:
+		if ch, ok := item.(*choice); ok {
+			def = ch.def
+		}
+		if ch, ok := item.(*multipleChoice); ok {
+			def = ch.def
+		}
+		for i, child := range Children(item) {
+			child = Rewrite(child, fn)
+			if child == nil {
+				if i < def {
+					def--
+				}
+				continue
+			}
+			items = append(items, child)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if def >= len(items) {
+			def = len(items) - 1
+		}
+	}
+
+	var rewritten RailItem
+	switch item := item.(type) {
+	case *skip:
+		rewritten = Skip()
+	case *terminal:
+		rewritten = Terminal(item.text, TerminalClass(item.class))
+	case *nonTerminal:
+		rewritten = NonTerminal(item.text, NonTerminalHref(item.href))
+	case *comment:
+		rewritten = Comment(item.text)
+	case *sequence:
+		rewritten = Sequence(items...)
+	case *stack:
+		rewritten = Stack(items...)
+	case *optionalSequence:
+		rewritten = OptionalSequence(items...)
+	case *choice:
+		rewritten = Choice(def, items...)
+	case *multipleChoice:
+		rewritten = MultipleChoice(def, MultipleChoiceType(item.type_), items...)
+	case *oneOrMore:
+		rewritten = OneOrMore(rewriteOrSkip(item.item, fn),
+			OneOrMoreRepeat(rewriteOrSkip(item.rep, fn)))
+	case *group:
+		rewritten = Group(rewriteOrSkip(item.item, fn), TextOf(item))
+	default:
+		rewritten = item
+	}
+	return fn(rewritten)
+}
+
+func rewriteOrSkip(item RailItem, fn func(RailItem) RailItem) RailItem {
+	if item = Rewrite(item, fn); item == nil {
+		return Skip()
+	}
+	return item
+}
+
+// Clone returns a copy of the item that can be drawn separately from it. Like
+// Rewrite, it shares the items the exported constructors don't return.
+func Clone(item RailItem) RailItem {
+	return Rewrite(item, func(item RailItem) RailItem { return item })
+}
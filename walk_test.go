@@ -0,0 +1,73 @@
+package railroad
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	item := Sequence(
+		Terminal("a"),
+		Choice(2, Comment("first"), NonTerminal("b"), Comment("default"), NonTerminal("c")),
+		OneOrMore(NonTerminal("b"), OneOrMoreRepeat(Comment("sep"))),
+		Group(Comment("only"), "label"),
+	)
+
+	var kinds []Kind
+	var texts []string
+	Walk(item, func(item RailItem) bool {
+		kinds = append(kinds, KindOf(item))
+		texts = append(texts, TextOf(item))
+		return KindOf(item) != KindGroup
+	})
+	if !reflect.DeepEqual(kinds, []Kind{
+		KindSequence, KindTerminal, KindChoice, KindComment, KindNonTerminal, KindComment,
+		KindNonTerminal, KindOneOrMore, KindNonTerminal, KindComment, KindGroup,
+	}) {
+		t.Fatalf("got kinds %q", kinds)
+	}
+	if texts[len(texts)-1] != "label" || texts[1] != "a" {
+		t.Fatalf("got texts %q", texts)
+	}
+
+	renamed := Rewrite(item, func(item RailItem) RailItem {
+		switch KindOf(item) {
+		case KindComment:
+			return nil
+		case KindNonTerminal:
+			return NonTerminal("renamed " + TextOf(item))
+		}
+		return item
+	})
+	seq := renamed.(*sequence)
+	if len(seq.items) != 4 {
+		t.Fatalf("got %d items", len(seq.items))
+	}
+	ch := seq.items[1].(*choice)
+	if len(ch.items) != 2 || ch.def != 1 || TextOf(ch.items[0]) != "renamed b" {
+		t.Fatalf("got choice %d of %d", ch.def, len(ch.items))
+	}
+	if KindOf(seq.items[2].(*oneOrMore).rep) != KindSkip || KindOf(seq.items[3].(*group).item) != KindSkip {
+		t.Fatal("expected removed single items to become skips")
+	}
+	if TextOf(item.(*sequence).items[0]) != "a" || KindOf(Children(Children(item)[1])[0]) != KindComment {
+		t.Fatal("expected the original to be unchanged")
+	}
+
+	if Rewrite(Sequence(Comment("a")), func(item RailItem) RailItem {
+		if KindOf(item) == KindComment {
+			return nil
+		}
+		return item
+	}) != nil {
+		t.Fatal("expected an emptied sequence to be removed")
+	}
+
+	clone := Clone(item)
+	add("walk original", Diagram(item))
+	add("walk clone", Diagram(clone))
+	if got := TextOf(Children(Children(item)[1])[0]); got != "first" {
+		t.Fatalf("expected drawing a choice to keep its order, got %q first", got)
+	}
+	add("walk", Diagram(renamed))
+}
@@ -0,0 +1,327 @@
+package railroad
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+type YaccOption struct {
+	lists *bool
+}
+
+// YaccLists controls if directly left or right recursive rules such as
+// `list: item | list ',' item` are drawn as repetitions instead of a
+// reference to themselves, as Grammar.Unrecurse draws them.
+func YaccLists(lists bool) YaccOption { return YaccOption{lists: &lists} }
+
+type yaccToken struct {
+	kind byte // 'i'dent, 'c'har, 's'tring, 'd'irective, '%' for %%, or punctuation
+	text string
+	line int
+}
+
+type yaccSymbol struct {
+	name     string
+	terminal bool
+}
+
+// ReadYacc reads the rules of a yacc, bison or goyacc grammar, producing a
+// Choice of Sequences for every nonterminal in the order they are defined.
+// Symbols declared with %token, %left, %right, %nonassoc or %precedence and
+// character or string literals are drawn as Terminals.
+func ReadYacc(r io.Reader, options ...YaccOption) ([]Production, error) {
+	var lists bool
+	for _, opt := range options {
+		if opt.lists != nil {
+			lists = *opt.lists
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	toks, err := lexYacc(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// declarations
+	tokens := map[string]bool{"error": true}
+	declaring := false
+	i := 0
+	for ; i < len(toks) && toks[i].kind != '%'; i++ {
+		switch tok := toks[i]; tok.kind {
+		case 'd':
+			switch tok.text {
+			case "%token", "%term", "%left", "%right", "%nonassoc", "%precedence":
+				declaring = true
+			default:
+				declaring = false
+			}
+		case 'i':
+			if declaring {
+				tokens[tok.text] = true
+			}
+		}
+	}
+	if i == len(toks) {
+		return nil, fmt.Errorf("yacc: missing %%%% before rules")
+	}
+	toks = toks[i+1:]
+
+	// rules
+	var names []string
+	lines := make(map[string]int)
+	rules := make(map[string][][]yaccSymbol)
+	bare := make(map[string]bool) // an alternative is empty without %empty
+	for i := 0; i < len(toks); {
+		if toks[i].kind == '%' {
+			break
+		}
+		if toks[i].kind == ';' {
+			i++
+			continue
+		}
+		if toks[i].kind != 'i' || i+1 >= len(toks) || toks[i+1].kind != ':' {
+			return nil, fmt.Errorf("yacc: line %d: expected rule name, found %q", toks[i].line, toks[i].text)
+		}
+		name := toks[i].text
+		if _, ok := rules[name]; !ok {
+			names = append(names, name)
+			lines[name] = toks[i].line
+			rules[name] = nil
+		}
+		i += 2
+
+		alt, marked := []yaccSymbol{}, false
+	body:
+		for ; i < len(toks); i++ {
+			switch tok := toks[i]; tok.kind {
+			case 'i':
+				if i+1 < len(toks) && toks[i+1].kind == ':' {
+					break body
+				}
+				alt = append(alt, yaccSymbol{name: tok.text, terminal: tokens[tok.text]})
+			case 'c', 's':
+				alt = append(alt, yaccSymbol{name: tok.text, terminal: true})
+			case 'd':
+				switch tok.text {
+				case "%prec", "%dprec", "%merge":
+					i++
+				case "%empty":
+					marked = true
+				default:
+					return nil, fmt.Errorf("yacc: line %d: unexpected %s in rule", tok.line, tok.text)
+				}
+			case '|':
+				rules[name] = append(rules[name], alt)
+				bare[name] = bare[name] || len(alt) == 0 && !marked
+				alt, marked = []yaccSymbol{}, false
+			case ';', '%':
+				break body
+			default:
+				return nil, fmt.Errorf("yacc: line %d: unexpected %q in rule", tok.line, tok.text)
+			}
+		}
+		rules[name] = append(rules[name], alt)
+		bare[name] = bare[name] || len(alt) == 0 && !marked
+	}
+
+	prods := make([]Production, 0, len(names))
+	for _, name := range names {
+		alts := make([]RailItem, 0, len(rules[name]))
+		for _, alt := range rules[name] {
+			alts = append(alts, yaccSequence(alt))
+		}
+		item := choiceOf(alts)
+		if ch, ok := item.(*choice); ok && ch.empty > 0 {
+			ch.bare = bare[name]
+		}
+		if lists {
+			if list := unrecurse(name, item); list != nil {
+				item = list
+			}
+		}
+		prods = append(prods, Production{Name: name, Item: item, Line: lines[name]})
+	}
+	return prods, nil
+}
+
+func yaccSequence(syms []yaccSymbol) RailItem {
+	items := make([]RailItem, 0, len(syms))
+	for _, sym := range syms {
+		if sym.terminal {
+			items = append(items, Terminal(sym.name))
+		} else {
+			items = append(items, NonTerminal(sym.name))
+		}
+	}
+	return sequenceOf(items)
+}
+
+// lexYacc splits the declarations and rules sections of a grammar into
+// tokens, dropping code blocks, actions, type tags and comments.
+func lexYacc(src string) ([]yaccToken, error) {
+	var toks []yaccToken
+	line := 1
+	sections := 0
+
+	// skipTo advances past the first occurrence of end, counting lines.
+	skipTo := func(i int, end string) (int, bool) {
+		j := strings.Index(src[i:], end)
+		if j < 0 {
+			return len(src), false
+		}
+		line += strings.Count(src[i:i+j+len(end)], "\n")
+		return i + j + len(end), true
+	}
+
+	// endOfLine returns the index of the newline ending the line at i.
+	endOfLine := func(i int) int {
+		if j := strings.IndexByte(src[i:], '\n'); j >= 0 {
+			return i + j
+		}
+		return len(src)
+	}
+
+	// skipQuoted advances past a quoted literal starting at i.
+	skipQuoted := func(i int) (int, bool) {
+		quote := src[i]
+		for i++; i < len(src); i++ {
+			switch src[i] {
+			case '\\':
+				i++
+			case '\n':
+				return i, false
+			case quote:
+				return i + 1, true
+			}
+		}
+		return i, false
+	}
+
+	for i := 0; i < len(src); {
+		start := line
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+
+		case strings.HasPrefix(src[i:], "/*"):
+			var ok bool
+			if i, ok = skipTo(i+2, "*/"); !ok {
+				return nil, fmt.Errorf("yacc: line %d: unterminated comment", start)
+			}
+
+		case strings.HasPrefix(src[i:], "//"):
+			i = endOfLine(i)
+
+		case strings.HasPrefix(src[i:], "%{"):
+			var ok bool
+			if i, ok = skipTo(i+2, "%}"); !ok {
+				return nil, fmt.Errorf("yacc: line %d: unterminated %%{", start)
+			}
+
+		case strings.HasPrefix(src[i:], "%%"):
+			toks = append(toks, yaccToken{kind: '%', text: "%%", line: line})
+			if sections++; sections == 2 {
+				return toks, nil
+			}
+			i += 2
+
+		case c == '{':
+			depth := 0
+			for ; i < len(src); i++ {
+				switch src[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				case '\n':
+					line++
+				case '\'', '"', '`':
+					if src[i] == '`' {
+						j, _ := skipTo(i+1, "`")
+						i = j - 1
+						continue
+					}
+					j, _ := skipQuoted(i)
+					i = j - 1
+					continue
+				case '/':
+					if strings.HasPrefix(src[i:], "/*") {
+						j, _ := skipTo(i+2, "*/")
+						i = j - 1
+					} else if strings.HasPrefix(src[i:], "//") {
+						i = endOfLine(i) - 1
+					}
+					continue
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("yacc: line %d: unterminated action", start)
+			}
+			i++
+
+		case c == '<' || c == '[':
+			end := ">"
+			if c == '[' {
+				end = "]"
+			}
+			var ok bool
+			if i, ok = skipTo(i+1, end); !ok {
+				return nil, fmt.Errorf("yacc: line %d: unterminated %c", start, c)
+			}
+
+		case c == '\'' || c == '"':
+			j, ok := skipQuoted(i)
+			if !ok {
+				return nil, fmt.Errorf("yacc: line %d: unterminated literal", start)
+			}
+			kind := byte('c')
+			if c == '"' {
+				kind = 's'
+			}
+			toks = append(toks, yaccToken{kind: kind, text: src[i+1 : j-1], line: line})
+			i = j
+
+		case c == '%' || isYaccIdent(c):
+			j := i + 1
+			for j < len(src) && (isYaccIdent(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			kind := byte('i')
+			if c == '%' {
+				kind = 'd'
+			}
+			toks = append(toks, yaccToken{kind: kind, text: src[i:j], line: line})
+			i = j
+
+		case c >= '0' && c <= '9', c == '=':
+			// token numbers, and the '=' some old grammars put before actions
+			i++
+
+		case c == ':' || c == '|' || c == ';':
+			toks = append(toks, yaccToken{kind: c, text: string(c), line: line})
+			i++
+
+		default:
+			return nil, fmt.Errorf("yacc: line %d: unexpected %q", start, c)
+		}
+	}
+	return toks, nil
+}
+
+func isYaccIdent(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '.'
+}
@@ -0,0 +1,72 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+)
+
+const testYacc = `
+%{
+package sql
+%}
+
+%union {
+	str string
+}
+
+%token <str> SELECT FROM IDENT
+%left ','
+
+%%
+
+query:
+	SELECT columns FROM IDENT opt_where
+	{ $$ = &Select{Columns: $2, From: $4} }
+;
+
+columns
+	: column
+	| columns ',' column { $$ = append($1, $3) }
+
+column: IDENT | '*' /* star */
+opt_where:
+	%empty
+|	WHERE expr { if $2 != nil { $$ = "}" } }
+;
+
+%%
+
+func unused() {}
+`
+
+func TestYacc(t *testing.T) {
+	prods, err := ReadYacc(strings.NewReader(testYacc), YaccLists(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, prod := range prods {
+		names = append(names, prod.Name)
+		add(prod.Name, Diagram(prod.Item))
+	}
+	if got := strings.Join(names, " "); got != "query columns column opt_where" {
+		t.Fatalf("got productions %q", got)
+	}
+	if prods[1].Line != 20 {
+		t.Fatalf("got line %d for columns", prods[1].Line)
+	}
+	if _, ok := prods[1].Item.(*oneOrMore); !ok {
+		t.Fatalf("expected columns to be a OneOrMore, got %T", prods[1].Item)
+	}
+	if _, ok := prods[3].Item.(*choice); !ok {
+		t.Fatalf("expected opt_where to be a Choice, got %T", prods[3].Item)
+	}
+
+	if _, err := ReadYacc(strings.NewReader("%%\nfoo: bar baz: ;")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadYacc(strings.NewReader("%token A\n")); err == nil {
+		t.Fatal("expected an error without a rules section")
+	}
+}